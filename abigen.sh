@@ -7,6 +7,11 @@ abigen -abi ./abis/entrypoint_v7.json \
     -type EntryPoint \
     -out ./bindings/entrypoint/entrypoint.go
 
+abigen -abi ./abis/entrypoint_v6.json \
+    -pkg entrypointv6 \
+    -type EntryPoint \
+    -out ./bindings/entrypointv6/entrypoint.go
+
 abigen -abi ./abis/simple_account_factory.json \
     -pkg account \
     -type SimpleAccountFactory \
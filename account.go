@@ -0,0 +1,164 @@
+package aasdk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/account"
+)
+
+// ComputeAccountAddress computes the counterfactual address of the SimpleAccountFactory account
+// of the owner and salt, without calling the chain.
+// The account is an ERC1967Proxy, created with CREATE2 by the factory, pointing to the implementation
+// and initialized with the owner.
+// The proxy creation code is required, it must be the exact type(ERC1967Proxy).creationCode compiled
+// into the factory, as returned by FetchAccountProxyCreationCode.
+func ComputeAccountAddress(factory, implementation common.Address, proxyCreationCode []byte, owner common.Address, salt *big.Int) (common.Address, error) {
+	if len(proxyCreationCode) == 0 {
+		return common.Address{}, fmt.Errorf("empty proxy creation code")
+	}
+	if salt == nil || salt.Sign() < 0 || salt.BitLen() > 256 {
+		return common.Address{}, fmt.Errorf("invalid salt %v", salt)
+	}
+	accountABI, err := account.SimpleAccountMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, fmt.Errorf("error getting simple account ABI: %v", err)
+	}
+	initialize, err := accountABI.Pack("initialize", owner)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("error packing initialize: %v", err)
+	}
+	constructorArgs, err := abi.Arguments{
+		{Type: abi.Type{T: abi.AddressTy}}, // implementation
+		{Type: abi.Type{T: abi.BytesTy}},   // initialization calldata
+	}.Pack(implementation, initialize)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("error packing proxy constructor arguments: %v", err)
+	}
+	initCode := append(append([]byte{}, proxyCreationCode...), constructorArgs...)
+	return crypto.CreateAddress2(factory, common.BigToHash(salt), crypto.Keccak256(initCode)), nil
+}
+
+var (
+	// proxyCodePrefix starts the creation code of a contract compiled by solc, setting the free memory pointer.
+	proxyCodePrefix = common.FromHex("0x6080604052")
+	// solcMetadataMarker is the "solc" key of the CBOR metadata ending the code compiled by solc,
+	// followed by the 3 bytes compiler version and the 2 bytes metadata length.
+	solcMetadataMarker = common.FromHex("0x64736f6c6343")
+)
+
+// FetchAccountProxyCreationCode returns the ERC1967Proxy creation code the deployed factory creates
+// the accounts with, to compute their addresses offline with ComputeAccountAddress.
+// Solidity embeds the type(ERC1967Proxy).creationCode in the factory code: the candidates are
+// extracted from it, and the one computing the address returned by the factory getAddress is returned.
+func FetchAccountProxyCreationCode(ctx context.Context, caller bind.ContractCaller, factory common.Address) ([]byte, error) {
+	code, err := caller.CodeAt(ctx, factory, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting factory code: %v", err)
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf("account factory %s is not deployed", factory.Hex())
+	}
+	simpleFactory, err := account.NewSimpleAccountFactoryCaller(factory, caller)
+	if err != nil {
+		return nil, fmt.Errorf("error creating account factory: %v", err)
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	implementation, err := simpleFactory.AccountImplementation(opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting account implementation: %v", err)
+	}
+	// Any owner and salt tell the creation code apart
+	owner, salt := factory, big.NewInt(0)
+	expected, err := simpleFactory.GetAddress(opts, owner, salt)
+	if err != nil {
+		return nil, fmt.Errorf("error getting account address: %v", err)
+	}
+	for _, candidate := range embeddedCreationCodes(code) {
+		addr, err := ComputeAccountAddress(factory, implementation, candidate, owner, salt)
+		if err == nil && addr == expected {
+			return candidate, nil
+		}
+	}
+	return nil, fmt.Errorf("no proxy creation code in factory %s computes its account addresses", factory.Hex())
+}
+
+// embeddedCreationCodes returns the contract creation codes compiled by solc embedded in the code:
+// the sequences from a creation code prefix to the end of the next solc metadata.
+func embeddedCreationCodes(code []byte) [][]byte {
+	var candidates [][]byte
+	for start := 1; start < len(code); start++ {
+		offset := bytes.Index(code[start:], proxyCodePrefix)
+		if offset < 0 {
+			break
+		}
+		start += offset
+		marker := bytes.Index(code[start:], solcMetadataMarker)
+		end := start + marker + len(solcMetadataMarker) + 3 + 2
+		if marker < 0 || end > len(code) {
+			break
+		}
+		candidates = append(candidates, code[start:end])
+	}
+	return candidates
+}
+
+// ComputeAccountAddress computes the account address offline with Config.AccountProxyCreationCode.
+// The account implementation is Config.AccountImplementation, or fetched once from the factory.
+func (c *Client) ComputeAccountAddress(ctx context.Context, owner common.Address, salt *big.Int) (common.Address, error) {
+	implementation, err := c.accountImplementation(ctx)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return ComputeAccountAddress(c.config.AccountFactory, implementation, c.config.AccountProxyCreationCode, owner, salt)
+}
+
+// getAccount computes the account address offline when the proxy creation code is configured,
+// or calls the factory getAddress.
+func (c *Client) getAccount(ctx context.Context, owner common.Address, salt *big.Int) (common.Address, error) {
+	if len(c.config.AccountProxyCreationCode) == 0 {
+		addr, err := c.simpleFactory.GetAddress(&bind.CallOpts{Context: ctx}, owner, salt)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("error getting account address: %v", err)
+		}
+		return addr, nil
+	}
+	addr, err := c.ComputeAccountAddress(ctx, owner, salt)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("error computing account address: %v", err)
+	}
+	if c.config.VerifyAccountAddress {
+		onchain, err := c.simpleFactory.GetAddress(&bind.CallOpts{Context: ctx}, owner, salt)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("error getting account address: %v", err)
+		}
+		if onchain != addr {
+			return common.Address{}, fmt.Errorf("account address mismatch: computed %s, factory returned %s", addr.Hex(), onchain.Hex())
+		}
+	}
+	return addr, nil
+}
+
+// accountImplementation returns the account implementation of the factory, fetched once.
+func (c *Client) accountImplementation(ctx context.Context) (common.Address, error) {
+	if c.config.AccountImplementation != (common.Address{}) {
+		return c.config.AccountImplementation, nil
+	}
+	c.implementationMu.Lock()
+	defer c.implementationMu.Unlock()
+	if c.implementation != (common.Address{}) {
+		return c.implementation, nil
+	}
+	implementation, err := c.simpleFactory.AccountImplementation(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return common.Address{}, fmt.Errorf("error getting account implementation: %v", err)
+	}
+	c.implementation = implementation
+	return implementation, nil
+}
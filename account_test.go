@@ -0,0 +1,170 @@
+package aasdk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/account"
+)
+
+// The expected address is built byte by byte from EIP-1014 and SimpleAccountFactory.getAddress:
+// keccak256(0xff ++ factory ++ salt ++ keccak256(creationCode ++ abi.encode(implementation,
+// abi.encodeCall(SimpleAccount.initialize, (owner)))))[12:].
+func TestComputeAccountAddress(t *testing.T) {
+	factory := common.HexToAddress("0x91E60e0613810449d098b0b5Ec8b51A0FE8c8985")
+	implementation := common.HexToAddress("0x8ABB13360b87Be5EEb1B98647A016adD927a136c")
+	owner := common.HexToAddress("0x1306b01bC3e4AD202612D3843387e94737673F53")
+	salt := big.NewInt(7)
+	creationCode := []byte{0x60, 0x80, 0x60, 0x40}
+
+	initialize := append(crypto.Keccak256([]byte("initialize(address)"))[:4], words(owner)...)
+	initCode := append(append([]byte{}, creationCode...), words(implementation, int64(0x40), int64(len(initialize)))...)
+	initCode = append(initCode, common.RightPadBytes(initialize, 64)...)
+	preimage := append(append([]byte{0xff}, factory.Bytes()...), words(salt)...)
+	expected := common.BytesToAddress(crypto.Keccak256(append(preimage, crypto.Keccak256(initCode)...))[12:])
+
+	addr, err := ComputeAccountAddress(factory, implementation, creationCode, owner, salt)
+	if err != nil {
+		t.Fatalf("Failed to compute account address: %v", err)
+	}
+	if addr != expected {
+		t.Errorf("Expected address %s, got %s", expected.Hex(), addr.Hex())
+	}
+	if other, _ := ComputeAccountAddress(factory, implementation, creationCode, owner, big.NewInt(8)); other == addr {
+		t.Errorf("Expected the address to depend on the salt")
+	}
+
+	if _, err := ComputeAccountAddress(factory, implementation, nil, owner, big.NewInt(0)); err == nil {
+		t.Errorf("Expected an error without creation code")
+	}
+	if _, err := ComputeAccountAddress(factory, implementation, creationCode, owner, big.NewInt(-1)); err == nil {
+		t.Errorf("Expected an error for a negative salt")
+	}
+}
+
+func TestVerifyAccountAddress(t *testing.T) {
+	factory := common.HexToAddress("0x91E60e0613810449d098b0b5Ec8b51A0FE8c8985")
+	implementation := common.HexToAddress("0x8ABB13360b87Be5EEb1B98647A016adD927a136c")
+	owner := common.HexToAddress("0x1306b01bC3e4AD202612D3843387e94737673F53")
+	creationCode := []byte{0x60, 0x80, 0x60, 0x40}
+	computed, err := ComputeAccountAddress(factory, implementation, creationCode, owner, big.NewInt(0))
+	if err != nil {
+		t.Fatalf("Failed to compute account address: %v", err)
+	}
+
+	// newClient returns a client whose factory getAddress returns the address
+	newClient := func(onchain common.Address, calls map[string]int) (*Client, func()) {
+		client, stop := newRpcStub(t, map[string]string{
+			"eth_call": `"0x` + common.Bytes2Hex(common.LeftPadBytes(onchain.Bytes(), 32)) + `"`,
+		}, calls)
+		client.config = &Config{
+			AccountFactory:           factory,
+			AccountImplementation:    implementation,
+			AccountProxyCreationCode: creationCode,
+			VerifyAccountAddress:     true,
+		}
+		client.simpleFactory, err = account.NewSimpleAccountFactory(factory, client.eth)
+		if err != nil {
+			t.Fatalf("Failed to create factory: %v", err)
+		}
+		return client, stop
+	}
+	ctx := context.Background()
+
+	// The computed address is cross-checked against the factory
+	calls := make(map[string]int)
+	client, stop := newClient(computed, calls)
+	defer stop()
+	if addr, err := client.GetAccount(ctx, owner, big.NewInt(0)); err != nil || addr != computed {
+		t.Errorf("Expected address %s, got %s, error %v", computed.Hex(), addr.Hex(), err)
+	}
+	if calls["eth_call"] != 1 {
+		t.Errorf("Expected one getAddress call, got %d", calls["eth_call"])
+	}
+
+	// A wrong creation code is reported instead of returning a wrong address
+	mismatch, stop := newClient(common.HexToAddress("0xaa"), nil)
+	defer stop()
+	if _, err := mismatch.GetAccount(ctx, owner, big.NewInt(0)); err == nil {
+		t.Errorf("Expected an address mismatch error")
+	}
+
+	// Without verification the factory isn't called
+	calls = make(map[string]int)
+	offline, stop := newClient(common.HexToAddress("0xaa"), calls)
+	defer stop()
+	offline.config.VerifyAccountAddress = false
+	if addr, err := offline.GetAccount(ctx, owner, big.NewInt(0)); err != nil || addr != computed {
+		t.Errorf("Expected address %s, got %s, error %v", computed.Hex(), addr.Hex(), err)
+	}
+	if calls["eth_call"] != 0 {
+		t.Errorf("Expected no factory call, got %d", calls["eth_call"])
+	}
+}
+
+// factoryCaller serves the code of a factory and answers accountImplementation and getAddress
+// with the addresses computed from the proxy creation code.
+type factoryCaller struct {
+	factory, implementation common.Address
+	code, proxyCreationCode []byte
+}
+
+func (c *factoryCaller) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return c.code, nil
+}
+
+func (c *factoryCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	switch {
+	case bytes.Equal(call.Data[:4], crypto.Keccak256([]byte("accountImplementation()"))[:4]):
+		return common.LeftPadBytes(c.implementation.Bytes(), 32), nil
+	case bytes.Equal(call.Data[:4], crypto.Keccak256([]byte("getAddress(address,uint256)"))[:4]):
+		owner := common.BytesToAddress(call.Data[4:36])
+		salt := new(big.Int).SetBytes(call.Data[36:68])
+		addr, err := ComputeAccountAddress(c.factory, c.implementation, c.proxyCreationCode, owner, salt)
+		if err != nil {
+			return nil, err
+		}
+		return common.LeftPadBytes(addr.Bytes(), 32), nil
+	}
+	return nil, fmt.Errorf("unexpected call %x", call.Data)
+}
+
+func TestFetchAccountProxyCreationCode(t *testing.T) {
+	// solcCode returns a code compiled by solc: the prefix, the body and the CBOR metadata
+	solcCode := func(body string) []byte {
+		return common.FromHex("0x6080604052" + body + "a264697066735822" + common.Bytes2Hex(make([]byte, 34)) + "64736f6c63430008170033")
+	}
+	proxyCode := solcCode("60405161")
+	decoyCode := solcCode("348015")
+	caller := &factoryCaller{
+		factory:           common.HexToAddress("0x91E60e0613810449d098b0b5Ec8b51A0FE8c8985"),
+		implementation:    common.HexToAddress("0x8ABB13360b87Be5EEb1B98647A016adD927a136c"),
+		proxyCreationCode: proxyCode,
+	}
+	// The factory runtime code, with the embedded creation codes after its body and its metadata last
+	caller.code = append(append(append(common.FromHex("0x60806040526004361061"), decoyCode...), proxyCode...), solcCode("")[5:]...)
+
+	code, err := FetchAccountProxyCreationCode(context.Background(), caller, caller.factory)
+	if err != nil {
+		t.Fatalf("Failed to fetch proxy creation code: %v", err)
+	}
+	if !bytes.Equal(code, proxyCode) {
+		t.Errorf("Expected proxy creation code %x, got %x", proxyCode, code)
+	}
+
+	// A factory creating the accounts with another code isn't matched
+	caller.proxyCreationCode = solcCode("600a")
+	if _, err := FetchAccountProxyCreationCode(context.Background(), caller, caller.factory); err == nil {
+		t.Errorf("Expected an error without a matching creation code")
+	}
+	caller.code = nil
+	if _, err := FetchAccountProxyCreationCode(context.Background(), caller, caller.factory); err == nil {
+		t.Errorf("Expected an error for a factory not deployed")
+	}
+}
@@ -2,107 +2,143 @@ package aasdk
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
+	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 const (
 	jsonrpcVersion     = "2.0"
 	defaultWaitTimeout = 30 * time.Second
+	// maxBatchSize is the maximum number of calls in a JSON-RPC batch request.
+	maxBatchSize = 100
 )
 
 func (c *Client) GetUserOpReceipt(ctx context.Context, hash common.Hash) (*UserOpReceipt, error) {
-	bytes, err := c.call("eth_getUserOperationReceipt", []any{hash})
+	bytes, err := c.call(ctx, "eth_getUserOperationReceipt", []any{hash})
 	if err != nil {
-		return nil, fmt.Errorf("error calling eth_getUserOperationReceipt: %v", err)
+		return nil, fmt.Errorf("error calling eth_getUserOperationReceipt: %w", err)
 	}
 	var response jsonRpcResponse[*UserOpReceipt]
 	if err = json.Unmarshal(bytes, &response); err != nil {
 		return nil, fmt.Errorf("error unmarshalling user op receipt: %v", err)
 	}
 	if response.Error != nil {
-		return nil, fmt.Errorf("error from bundler: %s", response.Error.String())
+		return nil, fmt.Errorf("error from bundler: %w", response.Error.toError())
 	}
 	return response.Result, nil
 }
 
+// GetUserOpReceipts returns the receipts of the user operations, in the order of hashes, nil for the pending ones.
+// The receipts are queried with JSON-RPC batch requests, falling back to one request per hash
+// when the bundler rejects batches or the batch request fails.
+func (c *Client) GetUserOpReceipts(ctx context.Context, hashes []common.Hash) ([]*UserOpReceipt, error) {
+	receipts := make([]*UserOpReceipt, len(hashes))
+	for start := 0; start < len(hashes); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(hashes))
+		params := make([][]any, end-start)
+		for i, hash := range hashes[start:end] {
+			params[i] = []any{hash}
+		}
+		responses, err := c.bundler.batchCall(ctx, "eth_getUserOperationReceipt", params)
+		if err != nil && ctx.Err() != nil {
+			return nil, fmt.Errorf("error calling eth_getUserOperationReceipt: %w", err)
+		}
+		for i := start; i < end; i++ {
+			if responses == nil || responses[i-start] == nil {
+				// Sequential fallback for the rejected batch or a missing response
+				receipt, err := c.GetUserOpReceipt(ctx, hashes[i])
+				if err != nil {
+					return nil, fmt.Errorf("error getting receipt of %s: %w", hashes[i].Hex(), err)
+				}
+				receipts[i] = receipt
+				continue
+			}
+			var response jsonRpcResponse[*UserOpReceipt]
+			if err = json.Unmarshal(responses[i-start], &response); err != nil {
+				return nil, fmt.Errorf("error unmarshalling user op receipt: %v", err)
+			}
+			if response.Error != nil {
+				return nil, fmt.Errorf("error getting receipt of %s: error from bundler: %w", hashes[i].Hex(), response.Error.toError())
+			}
+			receipts[i] = response.Result
+		}
+	}
+	return receipts, nil
+}
+
 func (c *Client) EstimateUserOpGas(ctx context.Context, userOp *UserOperation) (*GasEstimates, error) {
-	bytes, err := c.call("eth_estimateUserOperationGas", []any{userOp.ToBody(), c.config.Entrypoint})
+	bytes, err := c.call(ctx, "eth_estimateUserOperationGas", []any{c.toBody(userOp), c.config.Entrypoint})
 	if err != nil {
-		return nil, fmt.Errorf("error calling eth_estimateUserOperationGas: %v", err)
+		return nil, fmt.Errorf("error calling eth_estimateUserOperationGas: %w", err)
 	}
 	type gasEstimates struct {
-		PreVerificationGas   *string `json:"preVerificationGas"`
-		VerificationGasLimit *string `json:"verificationGasLimit"`
-		CallGasLimit         *string `json:"callGasLimit"`
-		VerificationGas      *string `json:"verificationGas"`
-		MaxFeePerGas         *string `json:"maxFeePerGas"`
-		MaxPriorityFeePerGas *string `json:"maxPriorityFeePerGas"`
+		PreVerificationGas   *hexutil.Big `json:"preVerificationGas"`
+		VerificationGasLimit *hexutil.Big `json:"verificationGasLimit"`
+		CallGasLimit         *hexutil.Big `json:"callGasLimit"`
+		VerificationGas      *hexutil.Big `json:"verificationGas"`
+		MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas"`
+		MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas"`
+
+		PaymasterVerificationGasLimit *hexutil.Big `json:"paymasterVerificationGasLimit"`
+		PaymasterPostOpGasLimit       *hexutil.Big `json:"paymasterPostOpGasLimit"`
 	}
 	var response jsonRpcResponse[*gasEstimates]
 	if err = json.Unmarshal(bytes, &response); err != nil {
 		return nil, fmt.Errorf("error unmarshalling user gas estimates: %v", err)
 	}
 	if response.Error != nil {
-		return nil, fmt.Errorf("error from bundler: %s", response.Error.String())
+		return nil, fmt.Errorf("error from bundler: %w", response.Error.toError())
 	}
 	if response.Result == nil {
 		return nil, fmt.Errorf("no gas estimates response")
 	}
 
-	result := &GasEstimates{}
-	if response.Result.PreVerificationGas != nil {
-		result.PreVerificationGas = HexToBigInt(*response.Result.PreVerificationGas)
-	}
-	if response.Result.VerificationGasLimit != nil {
-		result.VerificationGasLimit = HexToBigInt(*response.Result.VerificationGasLimit)
-	}
-	if response.Result.CallGasLimit != nil {
-		result.CallGasLimit = HexToBigInt(*response.Result.CallGasLimit)
-	}
-	if response.Result.VerificationGas != nil {
-		result.VerificationGas = HexToBigInt(*response.Result.VerificationGas)
-	}
-	if response.Result.MaxFeePerGas != nil {
-		result.MaxFeePerGas = HexToBigInt(*response.Result.MaxFeePerGas)
-	}
-	if response.Result.MaxPriorityFeePerGas != nil {
-		result.MaxPriorityFeePerGas = HexToBigInt(*response.Result.MaxPriorityFeePerGas)
-	}
-	return result, nil
+	return &GasEstimates{
+		PreVerificationGas:            (*big.Int)(response.Result.PreVerificationGas),
+		VerificationGasLimit:          (*big.Int)(response.Result.VerificationGasLimit),
+		CallGasLimit:                  (*big.Int)(response.Result.CallGasLimit),
+		VerificationGas:               (*big.Int)(response.Result.VerificationGas),
+		MaxFeePerGas:                  (*big.Int)(response.Result.MaxFeePerGas),
+		MaxPriorityFeePerGas:          (*big.Int)(response.Result.MaxPriorityFeePerGas),
+		PaymasterVerificationGasLimit: (*big.Int)(response.Result.PaymasterVerificationGasLimit),
+		PaymasterPostOpGasLimit:       (*big.Int)(response.Result.PaymasterPostOpGasLimit),
+	}, nil
 }
 
 func (c *Client) SupportedEntryPoints(ctx context.Context) ([]common.Address, error) {
-	bytes, err := c.call("eth_supportedEntryPoints", nil)
+	bytes, err := c.call(ctx, "eth_supportedEntryPoints", nil)
 	if err != nil {
-		return nil, fmt.Errorf("error calling eth_supportedEntryPoints: %v", err)
+		return nil, fmt.Errorf("error calling eth_supportedEntryPoints: %w", err)
 	}
 	var response jsonRpcResponse[[]common.Address]
 	if err = json.Unmarshal(bytes, &response); err != nil {
 		return nil, fmt.Errorf("error unmarshalling entry points: %v", err)
 	}
 	if response.Error != nil {
-		return nil, fmt.Errorf("error from bundler: %s", response.Error.String())
+		return nil, fmt.Errorf("error from bundler: %w", response.Error.toError())
 	}
 	return response.Result, nil
 }
 
-func (c *Client) SendUserOp(ctx context.Context, userOp *UserOperation, signer *ecdsa.PrivateKey) (common.Hash, error) {
-	signed, hash, err := c.FillAndSign(ctx, userOp, signer)
+func (c *Client) SendUserOp(ctx context.Context, userOp *UserOperation, signer Signer) (common.Hash, error) {
+	return c.SendUserOpWithOptions(ctx, userOp, signer, FillOptions{})
+}
+
+// SendUserOpWithOptions fills and signs the user operation with the options, and sends it to the bundler.
+func (c *Client) SendUserOpWithOptions(ctx context.Context, userOp *UserOperation, signer Signer, opts FillOptions) (common.Hash, error) {
+	signed, hash, err := c.FillAndSignWithOptions(ctx, userOp, signer, opts)
 	if err != nil {
-		return hash, fmt.Errorf("error fill and sign userop: %v", err)
+		return hash, fmt.Errorf("error fill and sign userop: %w", err)
 	}
 
-	bytes, err := c.call("eth_sendUserOperation", []any{signed.ToBody(), c.config.Entrypoint})
+	bytes, err := c.call(ctx, "eth_sendUserOperation", []any{c.toBody(signed), c.config.Entrypoint})
 	if err != nil {
-		return common.Hash{}, fmt.Errorf("error calling eth_sendUserOperation: %v", err)
+		return common.Hash{}, fmt.Errorf("error calling eth_sendUserOperation: %w", err)
 	}
 
 	var response jsonRpcResponse[common.Hash]
@@ -110,74 +146,83 @@ func (c *Client) SendUserOp(ctx context.Context, userOp *UserOperation, signer *
 		return common.Hash{}, fmt.Errorf("error unmarshalling when sending user operation: %v", err)
 	}
 	if response.Error != nil {
-		return common.Hash{}, fmt.Errorf("error from bundler: %s", response.Error.String())
+		return common.Hash{}, fmt.Errorf("error from bundler: %w", response.Error.toError())
 	}
 	return response.Result, nil
 }
 
-func (c *Client) GetUserOpHash(ctx context.Context, userOp *UserOperation, signer *ecdsa.PrivateKey) (common.Hash, error) {
+func (c *Client) GetUserOpHash(ctx context.Context, userOp *UserOperation, signer Signer) (common.Hash, error) {
 	_, hash, err := c.FillAndSign(ctx, userOp, signer)
 	if err != nil {
-		return common.Hash{}, fmt.Errorf("error fill and sign userop: %v", err)
+		return common.Hash{}, fmt.Errorf("error fill and sign userop: %w", err)
 	}
 	return hash, nil
 }
 
-func (c *Client) WaitForUserOperation(ctx context.Context, hash common.Hash) (*UserOpReceipt, error) {
-	ticker := time.NewTicker(c.config.WaitReceiptInterval)
-	defer ticker.Stop()
-	ctx, cancel := context.WithTimeout(ctx, defaultWaitTimeout)
-	defer cancel()
-	for {
-		select {
-		case <-ticker.C:
-			receipt, err := c.GetUserOpReceipt(ctx, hash)
-			if err != nil {
-				return nil, fmt.Errorf("error getting user operation receipt: %v", err)
-			}
-			if receipt != nil {
-				return receipt, nil
-			}
-		case <-ctx.Done():
-			return nil, fmt.Errorf("no receipt found for user operation %s", hash.Hex())
-		}
-	}
-}
-
-// call makes a JSON-RPC call to the bundler.
-func (c *Client) call(method string, params []any) ([]byte, error) {
-	if params == nil {
-		params = []any{}
+func (c *Client) GetUserOperationByHash(ctx context.Context, hash common.Hash) (*UserOperationByHash, error) {
+	type userOperationByHash struct {
+		UserOperation   *rpcUserOperation `json:"userOperation"`
+		EntryPoint      common.Address    `json:"entryPoint"`
+		BlockNumber     *hexutil.Big      `json:"blockNumber"`
+		BlockHash       *common.Hash      `json:"blockHash"`
+		TransactionHash *common.Hash      `json:"transactionHash"`
+	}
+	result, err := callBundler[*userOperationByHash](ctx, c, "eth_getUserOperationByHash", []any{hash})
+	if err != nil {
+		return nil, err
 	}
-
-	request := map[string]any{
-		"jsonrpc": jsonrpcVersion,
-		"id":      c.id.Add(1),
-		"method":  method,
-		"params":  params,
+	if result == nil || result.UserOperation == nil {
+		return nil, nil
 	}
-	payloadBytes, err := json.Marshal(request)
+	userOp, err := result.UserOperation.toUserOperation()
 	if err != nil {
-		return nil, fmt.Errorf("error marshalling payload: %v", err)
+		return nil, fmt.Errorf("error decoding user operation: %v", err)
 	}
+	byHash := &UserOperationByHash{
+		UserOperation: userOp,
+		EntryPoint:    result.EntryPoint,
+		BlockNumber:   (*big.Int)(result.BlockNumber),
+	}
+	if result.BlockHash != nil {
+		byHash.BlockHash = *result.BlockHash
+	}
+	if result.TransactionHash != nil {
+		byHash.TransactionHash = *result.TransactionHash
+	}
+	return byHash, nil
+}
 
-	payload := strings.NewReader(string(payloadBytes))
-	req, err := http.NewRequest("POST", c.config.BundlerUrl, payload)
+// BundlerChainId returns the chain ID reported by the bundler.
+func (c *Client) BundlerChainId(ctx context.Context) (*big.Int, error) {
+	result, err := callBundler[*hexutil.Big](ctx, c, "eth_chainId", nil)
 	if err != nil {
-		return nil, fmt.Errorf("error creating request: %v", err)
+		return nil, err
 	}
-	req.Header.Add("Content-Type", "application/json")
-	res, err := c.http.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error making request: %v", err)
+	if result == nil {
+		return nil, fmt.Errorf("no chain id response")
 	}
-	defer res.Body.Close()
+	return result.ToInt(), nil
+}
 
-	body, err := io.ReadAll(res.Body)
+// callBundler makes a JSON-RPC call to the bundler and unmarshals the result.
+func callBundler[T any](ctx context.Context, c *Client, method string, params []any) (T, error) {
+	var response jsonRpcResponse[T]
+	bytes, err := c.call(ctx, method, params)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %v", err)
+		return response.Result, fmt.Errorf("error calling %s: %w", method, err)
+	}
+	if err = json.Unmarshal(bytes, &response); err != nil {
+		return response.Result, fmt.Errorf("error unmarshalling %s response: %v", method, err)
+	}
+	if response.Error != nil {
+		return response.Result, fmt.Errorf("error from bundler: %w", response.Error.toError())
 	}
-	return body, nil
+	return response.Result, nil
+}
+
+// call makes a JSON-RPC call to the bundler.
+func (c *Client) call(ctx context.Context, method string, params []any) ([]byte, error) {
+	return c.bundler.call(ctx, method, params)
 }
 
 type jsonRpcResponse[T any] struct {
@@ -188,8 +233,9 @@ type jsonRpcResponse[T any] struct {
 }
 
 type errorResponse struct {
-	Code    *int    `json:"code"`
-	Message *string `json:"message"`
+	Code    *int            `json:"code"`
+	Message *string         `json:"message"`
+	Data    json.RawMessage `json:"data"`
 }
 
 // UnmarshalJSON implements custom unmarshaling for ErrorResponse
@@ -200,13 +246,15 @@ func (e *errorResponse) UnmarshalJSON(b []byte) error {
 		// If it's a string, set the message and leave code nil
 		e.Message = &errStr
 		e.Code = nil
+		e.Data = nil
 		return nil
 	}
 
 	// Otherwise, try to unmarshal as an object
 	type Alias struct {
-		Code    *int    `json:"code"`
-		Message *string `json:"message"`
+		Code    *int            `json:"code"`
+		Message *string         `json:"message"`
+		Data    json.RawMessage `json:"data"`
 	}
 	var alias Alias
 	if err := json.Unmarshal(b, &alias); err != nil {
@@ -216,19 +264,18 @@ func (e *errorResponse) UnmarshalJSON(b []byte) error {
 	// Populate the fields from the object
 	e.Code = alias.Code
 	e.Message = alias.Message
+	e.Data = alias.Data
 	return nil
 }
 
-func (e *errorResponse) String() string {
-	result := ""
+// toError converts the error response to a *BundlerError.
+func (e *errorResponse) toError() *BundlerError {
+	err := &BundlerError{Data: e.Data}
 	if e.Code != nil {
-		result += fmt.Sprintf("code: %d", *e.Code)
+		err.Code = *e.Code
 	}
 	if e.Message != nil {
-		if result != "" {
-			result += ", "
-		}
-		result += fmt.Sprintf("message: %s", *e.Message)
+		err.Message = *e.Message
 	}
-	return result
+	return err
 }
@@ -0,0 +1,91 @@
+package aasdk
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// minimalBundler implements the Bundler methods only, as the implementations outside the package.
+type minimalBundler struct{}
+
+func (minimalBundler) SendUserOp(ctx context.Context, userOp *UserOperation, signer Signer) (common.Hash, error) {
+	return common.Hash{}, nil
+}
+
+func (minimalBundler) EstimateUserOpGas(ctx context.Context, userOp *UserOperation) (*GasEstimates, error) {
+	return nil, nil
+}
+
+func (minimalBundler) GetUserOpReceipt(ctx context.Context, userOpHash common.Hash) (*UserOpReceipt, error) {
+	return nil, nil
+}
+
+func (minimalBundler) SupportedEntryPoints(ctx context.Context) ([]common.Address, error) {
+	return nil, nil
+}
+
+// The lookup and debug methods don't break the Bundler implementations.
+var _ Bundler = minimalBundler{}
+
+func TestRpcUserOperation(t *testing.T) {
+	// V0.6.0 operations carry initCode and paymasterAndData
+	raw := `{
+		"sender": "0x0000000000000000000000000000000000000001",
+		"nonce": "0x2",
+		"initCode": "0x00000000000000000000000000000000000000aa0102",
+		"callData": "0x",
+		"callGasLimit": "0x10",
+		"verificationGasLimit": "0x20",
+		"preVerificationGas": "0x30",
+		"maxFeePerGas": "0x40",
+		"maxPriorityFeePerGas": "0x50",
+		"paymasterAndData": "0x00000000000000000000000000000000000000bb03",
+		"signature": "0x04"
+	}`
+	var rpcOp rpcUserOperation
+	if err := json.Unmarshal([]byte(raw), &rpcOp); err != nil {
+		t.Fatalf("Failed to unmarshal user operation: %v", err)
+	}
+	userOp, err := rpcOp.toUserOperation()
+	if err != nil {
+		t.Fatalf("Failed to decode user operation: %v", err)
+	}
+	if userOp.Nonce.Int64() != 2 || userOp.MaxPriorityFeePerGas.Int64() != 0x50 {
+		t.Errorf("Unexpected nonce %s or priority fee %s", userOp.Nonce, userOp.MaxPriorityFeePerGas)
+	}
+	if userOp.Factory != common.HexToAddress("0xaa") || common.Bytes2Hex(userOp.FactoryData) != "0102" {
+		t.Errorf("Unexpected factory %s with data %x", userOp.Factory.Hex(), userOp.FactoryData)
+	}
+	if userOp.Paymaster != common.HexToAddress("0xbb") || common.Bytes2Hex(userOp.PaymasterData) != "03" {
+		t.Errorf("Unexpected paymaster %s with data %x", userOp.Paymaster.Hex(), userOp.PaymasterData)
+	}
+
+	// V0.7.0 operations carry the unpacked fields
+	raw = `{
+		"sender": "0x0000000000000000000000000000000000000001",
+		"nonce": "0x2",
+		"factory": "0x00000000000000000000000000000000000000aa",
+		"factoryData": "0x0102",
+		"callData": "0x",
+		"paymaster": "0x00000000000000000000000000000000000000bb",
+		"paymasterData": "0x03",
+		"paymasterVerificationGasLimit": "0x60",
+		"signature": "0x04"
+	}`
+	rpcOp = rpcUserOperation{}
+	if err := json.Unmarshal([]byte(raw), &rpcOp); err != nil {
+		t.Fatalf("Failed to unmarshal user operation: %v", err)
+	}
+	if userOp, err = rpcOp.toUserOperation(); err != nil {
+		t.Fatalf("Failed to decode user operation: %v", err)
+	}
+	if common.Bytes2Hex(userOp.InitCode) != "00000000000000000000000000000000000000aa0102" {
+		t.Errorf("Unexpected init code %x", userOp.InitCode)
+	}
+	if userOp.Paymaster != common.HexToAddress("0xbb") || userOp.PaymasterVerificationGasLimit.Int64() != 0x60 {
+		t.Errorf("Unexpected paymaster %s with verification gas %s", userOp.Paymaster.Hex(), userOp.PaymasterVerificationGasLimit)
+	}
+}
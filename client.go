@@ -2,12 +2,9 @@ package aasdk
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"fmt"
-	"math"
 	"math/big"
-	"net/http"
-	"sync/atomic"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
@@ -17,24 +14,30 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/lifenetwork-ai/aa-sdk-go/bindings/account"
 	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypoint"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypointv6"
 )
 
 var (
-	_ Bundler     = &Client{}
-	_ BaseAccount = &Client{}
+	_ Bundler      = &Client{}
+	_ DebugBundler = &Client{}
+	_ BaseAccount  = &Client{}
 )
 
 type Client struct {
-	id               atomic.Uint64 // unique id for the client
 	chainId          *big.Int
 	config           *Config
 	eth              *ethclient.Client
-	http             *http.Client
+	bundler          *bundlerPool
 	simpleFactory    *account.SimpleAccountFactory
 	entrypoint       *entrypoint.EntryPoint
+	entrypointV06    *entrypointv6.EntryPoint // only set for EntryPoint V0.6.0
+	version          EntryPointVersion
 	simpleAccountABI *abi.ABI
 	simpleFactoryABI *abi.ABI
 	lruCache         LRUCache
+
+	implementationMu sync.Mutex
+	implementation   common.Address // the account implementation of the factory, once fetched
 }
 
 // NewClient creates a new Client instance with given config.
@@ -51,6 +54,20 @@ func NewClient(config *Config, cache LRUCache) (*Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error creating entrypoint client: %v", err)
 	}
+	version := config.EntrypointVersion
+	var entrypointV06 *entrypointv6.EntryPoint
+	switch version {
+	case "":
+		version = EntryPointV07
+	case EntryPointV07, EntryPointV08:
+	case EntryPointV06:
+		entrypointV06, err = entrypointv6.NewEntryPoint(config.Entrypoint, eth)
+		if err != nil {
+			return nil, fmt.Errorf("error creating entrypoint client: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported entrypoint version: %s", config.EntrypointVersion)
+	}
 	simpleFactory, err := account.NewSimpleAccountFactory(config.AccountFactory, eth)
 	if err != nil {
 		return nil, fmt.Errorf("error creating account factory client: %v", err)
@@ -65,13 +82,14 @@ func NewClient(config *Config, cache LRUCache) (*Client, error) {
 	}
 
 	c := &Client{
-		id:               atomic.Uint64{},
 		chainId:          chainId,
 		eth:              eth,
-		http:             http.DefaultClient,
+		bundler:          newBundlerPool(config.bundlerUrls(), config.BundlerHeaders, config.HttpClient, config.Failover),
 		config:           config,
 		lruCache:         cache,
 		entrypoint:       entrypoint,
+		entrypointV06:    entrypointV06,
+		version:          version,
 		simpleFactory:    simpleFactory,
 		simpleAccountABI: simpleAccountABI,
 		simpleFactoryABI: simpleFactoryABI,
@@ -82,15 +100,15 @@ func NewClient(config *Config, cache LRUCache) (*Client, error) {
 // GetAccount returns the smart account address for the given owner and salt.
 func (c *Client) GetAccount(ctx context.Context, owner common.Address, salt *big.Int) (common.Address, error) {
 	if c.lruCache == nil {
-		return c.simpleFactory.GetAddress(&bind.CallOpts{}, owner, salt)
+		return c.getAccount(ctx, owner, salt)
 	}
 	key := fmt.Sprintf("%s-%s", owner.Hex(), salt.String())
 	if addr, ok := c.lruCache.Get(key); ok {
 		return addr.(common.Address), nil
 	}
-	addr, err := c.simpleFactory.GetAddress(&bind.CallOpts{}, owner, salt)
+	addr, err := c.getAccount(ctx, owner, salt)
 	if err != nil {
-		return common.Address{}, fmt.Errorf("error getting account address: %v", err)
+		return common.Address{}, err
 	}
 	c.lruCache.Set(key, addr)
 	return addr, nil
@@ -106,7 +124,13 @@ func (c *Client) GetAccountBalance(ctx context.Context, account common.Address)
 }
 
 // FillAndSign fills the user operation with default values and signs it.
-func (c *Client) FillAndSign(ctx context.Context, userOp *UserOperation, signer *ecdsa.PrivateKey) (*UserOperation, common.Hash, error) {
+func (c *Client) FillAndSign(ctx context.Context, userOp *UserOperation, signer Signer) (*UserOperation, common.Hash, error) {
+	return c.FillAndSignWithOptions(ctx, userOp, signer, FillOptions{})
+}
+
+// FillAndSignWithOptions fills the user operation with default values, sponsors it as chosen
+// by the options and signs it.
+func (c *Client) FillAndSignWithOptions(ctx context.Context, userOp *UserOperation, signer Signer, opts FillOptions) (*UserOperation, common.Hash, error) {
 	if userOp.Sender == (common.Address{}) {
 		return nil, common.Hash{}, fmt.Errorf("sender address is empty")
 	}
@@ -118,57 +142,122 @@ func (c *Client) FillAndSign(ctx context.Context, userOp *UserOperation, signer
 		userOp.Nonce = nonce
 	}
 
-	initCode, data, err := c.getInitCodeData(ctx, userOp.Sender, crypto.PubkeyToAddress(signer.PublicKey), userOp.Salt)
-	if err != nil {
-		return nil, common.Hash{}, fmt.Errorf("error getting account init code: %v", err)
+	var initCode, data []byte
+	if c.is7702Sender(userOp, signer) {
+		// The EOA delegates to the account implementation, there is nothing to deploy.
+		if err := c.fill7702Auth(ctx, userOp, signer); err != nil {
+			return nil, common.Hash{}, fmt.Errorf("error authorizing delegation: %w", err)
+		}
+	} else {
+		var err error
+		initCode, data, err = c.getInitCodeData(ctx, userOp.Sender, signer.Address(), userOp.Salt)
+		if err != nil {
+			return nil, common.Hash{}, fmt.Errorf("error getting account init code: %v", err)
+		}
 	}
 
 	if len(initCode) != 0 {
 		userOp.InitCode = initCode
 		userOp.Factory = c.config.AccountFactory
 		userOp.FactoryData = data
+	} else if IsEip7702InitCode(userOp.InitCode) {
+		// The caller's marker makes the entrypoint hash and initialize the EIP-7702 delegate.
+		userOp.Factory = Eip7702InitCodeMarker
+		userOp.FactoryData = nil
+		if len(userOp.InitCode) > common.AddressLength {
+			userOp.FactoryData = userOp.InitCode[common.AddressLength:]
+		}
 	} else {
 		userOp.InitCode = []byte{}
 	}
 
-	// Using paymaster default validation time
-	validAfter := big.NewInt(0)
-	validUntil := big.NewInt(math.MaxInt32)
-
-	paymasterData, err := EncodePaymasterData(validUntil, validAfter, EmptySignature)
-	if err != nil {
-		return nil, common.Hash{}, fmt.Errorf("error encoding paymaster data: %v", err)
-	}
-
-	userOp.Paymaster = c.config.PaymasterAddress
-
-	paymasterHash, err := GetPaymasterHash(&entrypoint.PackedUserOperation{
-		Sender:             userOp.Sender,
-		Nonce:              userOp.Nonce,
-		InitCode:           userOp.InitCode,
-		CallData:           userOp.CallData,
-		AccountGasLimits:   PackInt(userOp.VerificationGasLimit, userOp.CallGasLimit),
-		PreVerificationGas: userOp.PreVerificationGas,
-		GasFees:            PackInt(userOp.MaxPriorityFeePerGas, userOp.MaxFeePerGas),
-		PaymasterAndData:   PackPaymasterAndData(userOp.Paymaster, userOp.PaymasterVerificationGasLimit, userOp.PaymasterPostOpGasLimit, paymasterData),
-		Signature:          []byte{},
-	}, c.chainId, validUntil, validAfter)
-	if err != nil {
-		return nil, common.Hash{}, fmt.Errorf("error getting paymaster data: %v", err)
+	sponsorship := c.sponsorship(opts)
+	var provider PaymasterProvider
+	switch sponsorship {
+	case SponsorshipNone:
+		userOp.Paymaster = common.Address{}
+		userOp.PaymasterData = nil
+		userOp.PaymasterVerificationGasLimit = nil
+		userOp.PaymasterPostOpGasLimit = nil
+	case SponsorshipVerifyingPaymaster:
+		provider = NewVerifyingPaymasterV06(c.config.PaymasterAddress, c.config.VerifyingSigner, c.config.PaymasterValidity, c.eth)
+	case SponsorshipPaymasterService:
+		// Without provider, the paymaster fields of the user operation are used as is.
+		provider = opts.PaymasterProvider
+		if provider == nil {
+			provider = c.config.PaymasterProvider
+		}
+	default:
+		return nil, common.Hash{}, fmt.Errorf("unsupported sponsorship: %s", sponsorship)
 	}
-	paymasterSig, err := SignMessage(c.config.VerifyingSigner, paymasterHash.Bytes())
-	if err != nil {
-		return nil, common.Hash{}, fmt.Errorf("error signing paymaster data: %v", err)
+
+	if c.config.EstimateFees {
+		if err := c.fillFees(ctx, userOp); err != nil {
+			return nil, common.Hash{}, fmt.Errorf("error suggesting fees: %w", err)
+		}
 	}
-	paymasterData, err = EncodePaymasterData(validUntil, validAfter, paymasterSig)
-	if err != nil {
-		return nil, common.Hash{}, fmt.Errorf("error encoding paymaster data: %v", err)
+
+	var stub *PaymasterStubResult
+	if provider != nil {
+		var err error
+		stub, err = provider.GetPaymasterStubData(ctx, c.paymasterRequest(userOp, opts))
+		if err != nil {
+			return nil, common.Hash{}, fmt.Errorf("error getting paymaster stub data: %w", err)
+		}
+		userOp.Paymaster = stub.Paymaster
+		userOp.PaymasterData = stub.PaymasterData
+		stub.applyGasLimits(userOp)
 	}
-	userOp.PaymasterData = paymasterData
 
-	packed := PackUserOperation(userOp)
+	if c.config.EstimateGas {
+		if err := c.estimateGas(ctx, userOp); err != nil {
+			return nil, common.Hash{}, fmt.Errorf("error estimating gas: %w", err)
+		}
+		if stub != nil {
+			// The paymaster knows its gas limits better than the bundler.
+			stub.applyGasLimits(userOp)
+		}
+	}
+	// The paymaster gas limits are packed into paymasterAndData from V0.7.0.
+	if userOp.Paymaster != (common.Address{}) && c.version != EntryPointV06 &&
+		(userOp.PaymasterVerificationGasLimit == nil || userOp.PaymasterPostOpGasLimit == nil) {
+		return nil, common.Hash{}, fmt.Errorf("missing paymaster gas limits: set them on the user operation, return them from the paymaster stub data or enable EstimateGas")
+	}
 
-	sig, hash, err := c.SignUserOp(&packed, signer)
+	if sponsorship == SponsorshipNone && !opts.SkipPrefundCheck {
+		if err := c.checkPrefund(ctx, userOp); err != nil {
+			return nil, common.Hash{}, err
+		}
+	}
+	if stub != nil && !stub.IsFinal {
+		result, err := provider.GetPaymasterData(ctx, c.paymasterRequest(userOp, opts))
+		if err != nil {
+			return nil, common.Hash{}, fmt.Errorf("error getting paymaster data: %w", err)
+		}
+		userOp.Paymaster = result.Paymaster
+		userOp.PaymasterData = result.PaymasterData
+	}
+
+	var (
+		sig  []byte
+		hash common.Hash
+		err  error
+	)
+	if c.version == EntryPointV08 {
+		var delegate common.Address
+		delegate, err = c.userOpDelegate(ctx, userOp.Sender, userOp.Eip7702Auth, userOp.InitCode)
+		if err != nil {
+			return nil, common.Hash{}, fmt.Errorf("error hashing user operation: %v", err)
+		}
+		packed := PackUserOperation(userOp)
+		sig, hash, err = c.signUserOpV08(ctx, signer, &packed, delegate)
+	} else {
+		hash, err = c.userOpHash(ctx, userOp)
+		if err != nil {
+			return nil, common.Hash{}, fmt.Errorf("error hashing user operation: %v", err)
+		}
+		sig, err = SignMessage(ctx, signer, hash.Bytes())
+	}
 	if err != nil {
 		return nil, common.Hash{}, fmt.Errorf("error signing user operation: %v", err)
 	}
@@ -177,19 +266,125 @@ func (c *Client) FillAndSign(ctx context.Context, userOp *UserOperation, signer
 	return userOp, hash, nil
 }
 
-// SignUserOp signs a user operation using the provided private key.
-func (c *Client) SignUserOp(packed *entrypoint.PackedUserOperation, privateKey *ecdsa.PrivateKey) ([]byte, common.Hash, error) {
-	hash, err := GetUserOpHash(packed, c.config.Entrypoint, c.chainId)
+// paymasterRequest returns the request for the paymaster fields of the user operation.
+func (c *Client) paymasterRequest(userOp *UserOperation, opts FillOptions) *PaymasterRequest {
+	return &PaymasterRequest{
+		UserOp:            userOp,
+		Entrypoint:        c.config.Entrypoint,
+		EntrypointVersion: c.version,
+		ChainId:           c.chainId,
+		Context:           opts.PaymasterContext,
+		Validity:          opts.PaymasterValidity,
+	}
+}
+
+// SignUserOp signs a user operation using the provided signer.
+// The hash and signature scheme are the ones of the configured entrypoint version.
+func (c *Client) SignUserOp(ctx context.Context, packed *entrypoint.PackedUserOperation, signer Signer) ([]byte, common.Hash, error) {
+	if c.version == EntryPointV08 {
+		delegate, err := c.userOpDelegate(ctx, packed.Sender, nil, packed.InitCode)
+		if err != nil {
+			return nil, common.Hash{}, err
+		}
+		return c.signUserOpV08(ctx, signer, packed, delegate)
+	}
+	hash, err := c.packedUserOpHash(packed)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	sig, err := SignMessage(ctx, signer, hash.Bytes())
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	return sig, hash, nil
+}
+
+// signUserOpV08 signs the EIP-712 typed data of the EntryPoint V0.8.0 user operation and returns its hash.
+// V0.8.0 accounts verify the typed data hash as is, without the EIP-191 prefix.
+func (c *Client) signUserOpV08(ctx context.Context, signer Signer, packed *entrypoint.PackedUserOperation, delegate common.Address) ([]byte, common.Hash, error) {
+	hash, err := GetUserOpHashV08(packed, c.config.Entrypoint, c.chainId, delegate)
 	if err != nil {
 		return nil, common.Hash{}, err
 	}
-	sig, err := SignMessage(privateKey, hash.Bytes())
+	sig, err := signer.SignTypedData(ctx, UserOpTypedDataV08(packed, c.config.Entrypoint, c.chainId, delegate))
 	if err != nil {
 		return nil, common.Hash{}, err
 	}
 	return sig, hash, nil
 }
 
+// packedUserOpHash returns the hash of the packed user operation for EntryPoint V0.6.0 or V0.7.0.
+func (c *Client) packedUserOpHash(packed *entrypoint.PackedUserOperation) (common.Hash, error) {
+	if c.version == EntryPointV06 {
+		userOp, err := UnpackUserOperation(packed)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		packedV06 := PackUserOperationV06(userOp)
+		return GetUserOpHashV06(&packedV06, c.config.Entrypoint, c.chainId)
+	}
+	return GetUserOpHash(packed, c.config.Entrypoint, c.chainId)
+}
+
+// userOpHash returns the hash of the user operation for the configured entrypoint version.
+func (c *Client) userOpHash(ctx context.Context, userOp *UserOperation) (common.Hash, error) {
+	switch c.version {
+	case EntryPointV06:
+		packed := PackUserOperationV06(userOp)
+		return GetUserOpHashV06(&packed, c.config.Entrypoint, c.chainId)
+	case EntryPointV08:
+		delegate, err := c.userOpDelegate(ctx, userOp.Sender, userOp.Eip7702Auth, userOp.InitCode)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		packed := PackUserOperation(userOp)
+		return GetUserOpHashV08(&packed, c.config.Entrypoint, c.chainId, delegate)
+	default:
+		packed := PackUserOperation(userOp)
+		return GetUserOpHash(&packed, c.config.Entrypoint, c.chainId)
+	}
+}
+
+// userOpDelegate returns the EIP-7702 delegate a V0.8.0 user operation is hashed with:
+// the authorized one, or the current one of the sender when the initCode has the EIP-7702 marker.
+func (c *Client) userOpDelegate(ctx context.Context, sender common.Address, auth *Eip7702Auth, initCode []byte) (common.Address, error) {
+	if auth != nil {
+		return auth.Address, nil
+	}
+	if !IsEip7702InitCode(initCode) {
+		return common.Address{}, nil
+	}
+	delegate, _, err := GetEip7702Delegate(ctx, c.eth, sender)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("error getting account delegate: %v", err)
+	}
+	return delegate, nil
+}
+
+// toBody converts the user operation to the RPC format of the configured entrypoint version.
+func (c *Client) toBody(userOp *UserOperation) any {
+	return userOpBody(userOp, c.version)
+}
+
+// userOpBody converts the user operation to the RPC format of the entrypoint version.
+func userOpBody(userOp *UserOperation, version EntryPointVersion) any {
+	switch version {
+	case EntryPointV06:
+		return userOp.ToBodyV06()
+	case EntryPointV08:
+		return userOp.ToBodyV08()
+	default:
+		return userOp.ToBody()
+	}
+}
+
+// is7702Sender checks if the user operation is sent from the signer's EOA delegated with EIP-7702.
+func (c *Client) is7702Sender(userOp *UserOperation, signer Signer) bool {
+	return c.version == EntryPointV08 &&
+		c.config.Eip7702Delegate != (common.Address{}) &&
+		userOp.Sender == signer.Address()
+}
+
 func (c *Client) getInitCodeData(ctx context.Context, account common.Address, owner common.Address, salt *big.Int) ([]byte, []byte, error) {
 	isDeployed, err := IsAccountDeployed(ctx, c.eth, account)
 	if err != nil {
@@ -208,67 +403,82 @@ func (c *Client) getInitCodeData(ctx context.Context, account common.Address, ow
 }
 
 // HandleOps handles the user operations by calling the entrypoint contract directly.
+// It returns the user operation hashes, as in the UserOperationEvent, and the transaction hash.
 func (c *Client) HandleOps(ctx context.Context, ops []entrypoint.PackedUserOperation) ([]common.Hash, common.Hash, error) {
-	if c.config.ExecutorSigners.Count() == 0 {
-		panic("no execution signer provided")
+	opHashes, err := c.userOpHashes(ctx, ops)
+	if err != nil {
+		return []common.Hash{}, common.Hash{}, err
 	}
+	txHash, err := c.sendWithExecutor(ctx, "handling ops", func(opts *bind.TransactOpts, beneficiary common.Address) (*types.Transaction, error) {
+		return c.entrypoint.HandleOps(opts, ops, beneficiary)
+	})
+	if err != nil {
+		return []common.Hash{}, common.Hash{}, err
+	}
+	return opHashes, txHash, nil
+}
 
-	// Get one signer from the rotation for use
-	executorSigner := c.config.ExecutorSigners.Next()
-
-	txOpts, err := bind.NewKeyedTransactorWithChainID(executorSigner, c.chainId)
+// HandleAtomicOps handles the user operations with atomic mode by calling the entrypoint contract directly.
+// It returns the user operation hashes, as in the UserOperationEvent, and the transaction hash.
+func (c *Client) HandleAtomicOps(ctx context.Context, ops []entrypoint.PackedUserOperation) ([]common.Hash, common.Hash, error) {
+	opHashes, err := c.userOpHashes(ctx, ops)
 	if err != nil {
-		return []common.Hash{}, common.Hash{}, fmt.Errorf("error creating transaction options: %v", err)
+		return []common.Hash{}, common.Hash{}, err
 	}
-	tx, err := c.entrypoint.HandleOps(txOpts, ops, crypto.PubkeyToAddress(executorSigner.PublicKey))
+	txHash, err := c.sendWithExecutor(ctx, "handling atomic ops", func(opts *bind.TransactOpts, beneficiary common.Address) (*types.Transaction, error) {
+		return c.entrypoint.HandleAtomicOps(opts, ops, beneficiary)
+	})
 	if err != nil {
-		return []common.Hash{}, common.Hash{}, fmt.Errorf("error handling ops: %v", err)
+		return []common.Hash{}, common.Hash{}, err
 	}
+	return opHashes, txHash, nil
+}
+
+// userOpHashes returns the hash of every packed user operation for the configured entrypoint version.
+func (c *Client) userOpHashes(ctx context.Context, ops []entrypoint.PackedUserOperation) ([]common.Hash, error) {
 	var opHashes []common.Hash
 	for _, op := range ops {
-		hashed, err := HashedUserOp(&op)
+		userOp, err := UnpackUserOperation(&op)
 		if err != nil {
-			return []common.Hash{}, common.Hash{}, fmt.Errorf("error hashing user operation: %v", err)
+			return nil, fmt.Errorf("error unpacking user operation: %v", err)
 		}
-		opHashes = append(opHashes, hashed)
+		hash, err := c.userOpHash(ctx, userOp)
+		if err != nil {
+			return nil, fmt.Errorf("error hashing user operation: %v", err)
+		}
+		opHashes = append(opHashes, hash)
 	}
-	return opHashes, tx.Hash(), nil
+	return opHashes, nil
 }
 
-// HandleAtomicOps handles the user operations with atomic mode by calling the entrypoint contract directly.
-func (c *Client) HandleAtomicOps(ctx context.Context, ops []entrypoint.PackedUserOperation) ([]common.Hash, common.Hash, error) {
+// sendWithExecutor sends an entrypoint transaction from the next executor signer, which is also the beneficiary,
+// and returns the transaction hash. The entrypoint errors are decoded.
+func (c *Client) sendWithExecutor(ctx context.Context, action string, send func(opts *bind.TransactOpts, beneficiary common.Address) (*types.Transaction, error)) (common.Hash, error) {
 	if c.config.ExecutorSigners.Count() == 0 {
 		panic("no execution signer provided")
 	}
 
 	// Get one signer from the rotation for use
-	executorSigner := c.config.ExecutorSigners.Next()
+	executorSigner, err := nextKey(c.config.ExecutorSigners)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("no execution signer available: %w", err)
+	}
 
 	txOpts, err := bind.NewKeyedTransactorWithChainID(executorSigner, c.chainId)
 	if err != nil {
-		return []common.Hash{}, common.Hash{}, fmt.Errorf("error creating transaction options: %v", err)
+		return common.Hash{}, fmt.Errorf("error creating transaction options: %v", err)
 	}
-	tx, err := c.entrypoint.HandleAtomicOps(txOpts, ops, crypto.PubkeyToAddress(executorSigner.PublicKey))
+	txOpts.Context = ctx
+	tx, err := send(txOpts, crypto.PubkeyToAddress(executorSigner.PublicKey))
 	if err != nil {
-		return []common.Hash{}, common.Hash{}, fmt.Errorf("error handling atomic ops: %v", err)
-	}
-	var opHashes []common.Hash
-	for _, op := range ops {
-		hashed, err := HashedUserOp(&op)
-		if err != nil {
-			return []common.Hash{}, common.Hash{}, fmt.Errorf("error hashing user operation: %v", err)
-		}
-		opHashes = append(opHashes, hashed)
+		return common.Hash{}, fmt.Errorf("error %s: %w", action, DecodeEntryPointError(err))
 	}
-	return opHashes, tx.Hash(), nil
+	return tx.Hash(), nil
 }
 
 // Prefund deposits to entrypoint and waits for the transaction to be mined.
 func (c *Client) Prefund(ctx context.Context, to common.Address, amount *big.Int) (*types.Receipt, error) {
-	txOpts, err := bind.NewKeyedTransactorWithChainID(c.config.VerifyingSigner, c.chainId)
-	if err != nil {
-		return nil, fmt.Errorf("error creating transactor: %v", err)
-	}
+	txOpts := newTransactor(ctx, c.config.VerifyingSigner, c.chainId)
 	txOpts.Value = amount
 	tx, err := c.entrypoint.DepositTo(txOpts, to)
 	if err != nil {
@@ -278,11 +488,9 @@ func (c *Client) Prefund(ctx context.Context, to common.Address, amount *big.Int
 }
 
 // DeployAccount deploys the smart account and waits for the transaction to be mined.
-func (c *Client) DeployAccount(ctx context.Context, signer *ecdsa.PrivateKey, account common.Address, salt *big.Int) (*types.Receipt, error) {
-	txOpts, err := bind.NewKeyedTransactorWithChainID(signer, c.chainId)
-	if err != nil {
-		return nil, fmt.Errorf("error creating transactor: %v", err)
-	}
+// The transaction is sent from the signer.
+func (c *Client) DeployAccount(ctx context.Context, signer Signer, account common.Address, salt *big.Int) (*types.Receipt, error) {
+	txOpts := newTransactor(ctx, signer, c.chainId)
 	tx, err := c.simpleFactory.CreateAccount(txOpts, account, salt)
 	if err != nil {
 		return nil, fmt.Errorf("error creating account: %v", err)
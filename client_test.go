@@ -0,0 +1,314 @@
+package aasdk
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypoint"
+)
+
+type fakePaymasterKey struct{}
+
+// fakePaymaster returns the stub and data results, and records the requests and their context values.
+type fakePaymaster struct {
+	stub *PaymasterStubResult
+	data *PaymasterResult
+
+	stubCalls, dataCalls []*PaymasterRequest
+	values               []any
+}
+
+var _ PaymasterProvider = (*fakePaymaster)(nil)
+
+func (p *fakePaymaster) GetPaymasterStubData(ctx context.Context, req *PaymasterRequest) (*PaymasterStubResult, error) {
+	p.stubCalls = append(p.stubCalls, req)
+	p.values = append(p.values, ctx.Value(fakePaymasterKey{}))
+	stub := *p.stub
+	return &stub, nil
+}
+
+func (p *fakePaymaster) GetPaymasterData(ctx context.Context, req *PaymasterRequest) (*PaymasterResult, error) {
+	p.dataCalls = append(p.dataCalls, req)
+	p.values = append(p.values, ctx.Value(fakePaymasterKey{}))
+	data := *p.data
+	return &data, nil
+}
+
+// newFillClient returns a client estimating the gas with a stub bundler, for a deployed account.
+func newFillClient(t *testing.T) (*Client, func()) {
+	client, stop := newRpcStub(t, map[string]string{
+		"eth_getCode": `"0x6000"`,
+		"eth_estimateUserOperationGas": `{
+			"preVerificationGas":"0x3e8",
+			"verificationGasLimit":"0x2710",
+			"callGasLimit":"0x2710",
+			"paymasterVerificationGasLimit":"0x64",
+			"paymasterPostOpGasLimit":"0x5"
+		}`,
+	}, nil)
+	client.config.Entrypoint = common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+	client.config.EstimateGas = true
+	client.config.GasMultipliers = DefaultGasMultipliers
+	return client, stop
+}
+
+// fillUserOp returns a user operation of a deployed account with its fees.
+func fillUserOp() *UserOperation {
+	return &UserOperation{
+		Sender:               common.HexToAddress("0x01"),
+		Nonce:                big.NewInt(0),
+		CallData:             []byte{},
+		MaxFeePerGas:         big.NewInt(3000000000),
+		MaxPriorityFeePerGas: big.NewInt(1000000000),
+	}
+}
+
+func TestFillAndSignPaymasterProvider(t *testing.T) {
+	client, stop := newFillClient(t)
+	defer stop()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer := NewPrivateKeySigner(key)
+	paymaster := &fakePaymaster{
+		stub: &PaymasterStubResult{
+			PaymasterResult:               PaymasterResult{Paymaster: common.HexToAddress("0xaa"), PaymasterData: []byte{0x01}},
+			PaymasterVerificationGasLimit: big.NewInt(0x1234),
+		},
+		data: &PaymasterResult{Paymaster: common.HexToAddress("0xaa"), PaymasterData: []byte{0x02}},
+	}
+	ctx := context.WithValue(context.Background(), fakePaymasterKey{}, "value")
+	opts := FillOptions{
+		Sponsorship:       SponsorshipPaymasterService,
+		PaymasterProvider: paymaster,
+		PaymasterContext:  map[string]any{"policyId": "abc"},
+	}
+
+	signed, _, err := client.FillAndSignWithOptions(ctx, fillUserOp(), signer, opts)
+	if err != nil {
+		t.Fatalf("Failed to fill and sign: %v", err)
+	}
+	if len(paymaster.stubCalls) != 1 || len(paymaster.dataCalls) != 1 {
+		t.Fatalf("Expected one stub and one data call, got %d and %d", len(paymaster.stubCalls), len(paymaster.dataCalls))
+	}
+	if signed.Paymaster != common.HexToAddress("0xaa") || !bytes.Equal(signed.PaymasterData, []byte{0x02}) {
+		t.Errorf("Expected the final paymaster data, got %s with %x", signed.Paymaster.Hex(), signed.PaymasterData)
+	}
+
+	// The stub gas limit overrides the bundler estimate, the other one is estimated
+	if signed.PaymasterVerificationGasLimit.Int64() != 0x1234 {
+		t.Errorf("Expected the stub paymaster verification gas limit, got %s", signed.PaymasterVerificationGasLimit)
+	}
+	if signed.PaymasterPostOpGasLimit.Int64() != 6 {
+		t.Errorf("Expected the estimated paymaster postOp gas limit, got %s", signed.PaymasterPostOpGasLimit)
+	}
+	if req := paymaster.dataCalls[0]; req.UserOp.PaymasterVerificationGasLimit.Int64() != 0x1234 || req.UserOp.CallGasLimit.Int64() != 12000 {
+		t.Errorf("Expected the data request with the final gas limits, got %+v", req.UserOp)
+	}
+
+	// The context and the paymaster context reach the provider
+	for i, value := range paymaster.values {
+		if value != "value" {
+			t.Errorf("Expected the context value in call %d, got %v", i, value)
+		}
+	}
+	for _, req := range append(paymaster.stubCalls, paymaster.dataCalls...) {
+		if req.Context["policyId"] != "abc" || req.ChainId.Int64() != 1 || req.Entrypoint != client.config.Entrypoint {
+			t.Errorf("Unexpected paymaster request %+v", req)
+		}
+	}
+
+	// The final stub data is used as is
+	paymaster = &fakePaymaster{
+		stub: &PaymasterStubResult{
+			PaymasterResult: PaymasterResult{Paymaster: common.HexToAddress("0xbb"), PaymasterData: []byte{0x03}},
+			IsFinal:         true,
+		},
+	}
+	opts.PaymasterProvider = paymaster
+	signed, _, err = client.FillAndSignWithOptions(ctx, fillUserOp(), signer, opts)
+	if err != nil {
+		t.Fatalf("Failed to fill and sign: %v", err)
+	}
+	if len(paymaster.stubCalls) != 1 || len(paymaster.dataCalls) != 0 {
+		t.Errorf("Expected only the stub call, got %d stub and %d data calls", len(paymaster.stubCalls), len(paymaster.dataCalls))
+	}
+	if signed.Paymaster != common.HexToAddress("0xbb") || !bytes.Equal(signed.PaymasterData, []byte{0x03}) {
+		t.Errorf("Expected the stub paymaster data, got %s with %x", signed.Paymaster.Hex(), signed.PaymasterData)
+	}
+	if signed.PaymasterVerificationGasLimit.Int64() != 120 {
+		t.Errorf("Expected the estimated paymaster verification gas limit, got %s", signed.PaymasterVerificationGasLimit)
+	}
+}
+
+func TestFillAndSignMissingPaymasterGasLimits(t *testing.T) {
+	client, stop := newFillClient(t)
+	defer stop()
+	client.config.EstimateGas = false
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer := NewPrivateKeySigner(key)
+	paymaster := &fakePaymaster{
+		stub: &PaymasterStubResult{
+			PaymasterResult: PaymasterResult{Paymaster: common.HexToAddress("0xaa"), PaymasterData: []byte{0x01}},
+		},
+		data: &PaymasterResult{Paymaster: common.HexToAddress("0xaa"), PaymasterData: []byte{0x02}},
+	}
+	opts := FillOptions{
+		Sponsorship:       SponsorshipPaymasterService,
+		PaymasterProvider: paymaster,
+		SkipPrefundCheck:  true,
+	}
+	userOp := fillUserOp()
+	userOp.CallGasLimit = big.NewInt(100000)
+	userOp.VerificationGasLimit = big.NewInt(100000)
+	userOp.PreVerificationGas = big.NewInt(50000)
+
+	// Without the stub limits nor estimation, the missing limits are reported
+	if _, _, err := client.FillAndSignWithOptions(context.Background(), userOp, signer, opts); err == nil {
+		t.Fatalf("Expected an error for the missing paymaster gas limits")
+	}
+	if len(paymaster.dataCalls) != 0 {
+		t.Errorf("Expected no paymaster data call, got %d", len(paymaster.dataCalls))
+	}
+
+	// The limits of the user operation are kept
+	userOp.PaymasterVerificationGasLimit = big.NewInt(100)
+	userOp.PaymasterPostOpGasLimit = big.NewInt(5)
+	signed, _, err := client.FillAndSignWithOptions(context.Background(), userOp, signer, opts)
+	if err != nil {
+		t.Fatalf("Failed to fill and sign: %v", err)
+	}
+	if signed.PaymasterVerificationGasLimit.Int64() != 100 || signed.PaymasterPostOpGasLimit.Int64() != 5 {
+		t.Errorf("Unexpected paymaster gas limits %s and %s", signed.PaymasterVerificationGasLimit, signed.PaymasterPostOpGasLimit)
+	}
+}
+
+func TestFillAndSignVerifyingPaymaster(t *testing.T) {
+	client, stop := newFillClient(t)
+	defer stop()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	paymasterKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	verifyingSigner := NewPrivateKeySigner(paymasterKey)
+	client.config.PaymasterAddress = common.HexToAddress("0xaa")
+	client.config.VerifyingSigner = verifyingSigner
+
+	signed, _, err := client.FillAndSign(context.Background(), fillUserOp(), NewPrivateKeySigner(key))
+	if err != nil {
+		t.Fatalf("Failed to fill and sign: %v", err)
+	}
+	if signed.Paymaster != client.config.PaymasterAddress {
+		t.Errorf("Expected paymaster %s, got %s", client.config.PaymasterAddress.Hex(), signed.Paymaster.Hex())
+	}
+	packed := PackUserOperation(signed)
+	recovered, parsed, _, err := RecoverPaymasterSigner(&packed, client.chainId)
+	if err != nil {
+		t.Fatalf("Failed to recover paymaster signer: %v", err)
+	}
+	if recovered != verifyingSigner.Address() {
+		t.Errorf("Expected paymaster signer %s, got %s", verifyingSigner.Address().Hex(), recovered.Hex())
+	}
+	if parsed.VerificationGasLimit.Int64() != 120 || parsed.PostOpGasLimit.Int64() != 6 {
+		t.Errorf("Expected the estimated paymaster gas limits to be signed, got %+v", parsed)
+	}
+}
+
+func TestFillAndSignPaymasterValidity(t *testing.T) {
+	client, stop := newFillClient(t)
+	defer stop()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	paymasterKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	client.config.PaymasterAddress = common.HexToAddress("0xaa")
+	client.config.VerifyingSigner = NewPrivateKeySigner(paymasterKey)
+	client.config.PaymasterValidity = time.Hour
+	signer := NewPrivateKeySigner(key)
+
+	// The config validity is from signing
+	start := time.Now().Truncate(time.Second)
+	signed, _, err := client.FillAndSign(context.Background(), fillUserOp(), signer)
+	if err != nil {
+		t.Fatalf("Failed to fill and sign: %v", err)
+	}
+	validity, err := signed.PaymasterValidity()
+	if err != nil {
+		t.Fatalf("Failed to decode paymaster validity: %v", err)
+	}
+	if validity.ValidUntil.Before(start.Add(time.Hour)) || validity.ValidUntil.After(time.Now().Add(time.Hour)) || !validity.ValidAfter.IsZero() {
+		t.Errorf("Expected a window of an hour from signing, got %+v", validity)
+	}
+
+	// The options window is signed as is
+	window := PaymasterValidity{ValidUntil: time.Unix(1800000000, 0), ValidAfter: time.Unix(1700000000, 0)}
+	signed, _, err = client.FillAndSignWithOptions(context.Background(), fillUserOp(), signer, FillOptions{PaymasterValidity: &window})
+	if err != nil {
+		t.Fatalf("Failed to fill and sign: %v", err)
+	}
+	validity, err = signed.PaymasterValidity()
+	if err != nil {
+		t.Fatalf("Failed to decode paymaster validity: %v", err)
+	}
+	if !validity.ValidUntil.Equal(window.ValidUntil) || !validity.ValidAfter.Equal(window.ValidAfter) {
+		t.Errorf("Expected window %+v, got %+v", window, validity)
+	}
+	packed := PackUserOperation(signed)
+	if recovered, _, _, err := RecoverPaymasterSigner(&packed, client.chainId); err != nil || recovered != client.config.VerifyingSigner.Address() {
+		t.Errorf("Expected the window to be signed by the verifying signer, got %s, error %v", recovered.Hex(), err)
+	}
+}
+
+func TestUserOpHashes(t *testing.T) {
+	client := &Client{
+		chainId: big.NewInt(1),
+		config:  &Config{Entrypoint: common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")},
+	}
+	userOp := fillUserOp()
+	userOp.CallGasLimit = big.NewInt(10000)
+	userOp.VerificationGasLimit = big.NewInt(20000)
+	userOp.PreVerificationGas = big.NewInt(1000)
+	packed := PackUserOperation(userOp)
+	ctx := context.Background()
+
+	// The full user operation hashes are returned, as the UserOperationEvent and the bundlers report them
+	for _, version := range []EntryPointVersion{EntryPointV07, EntryPointV08, EntryPointV06} {
+		client.version = version
+		expected, err := client.userOpHash(ctx, userOp)
+		if err != nil {
+			t.Fatalf("Failed to hash user operation: %v", err)
+		}
+		hashes, err := client.userOpHashes(ctx, []entrypoint.PackedUserOperation{packed})
+		if err != nil {
+			t.Fatalf("Failed to hash user operations: %v", err)
+		}
+		if len(hashes) != 1 || hashes[0] != expected {
+			t.Errorf("Expected %s hash %s, got %v", version, expected.Hex(), hashes)
+		}
+	}
+	expected, err := GetUserOpHash(&packed, client.config.Entrypoint, client.chainId)
+	if err != nil {
+		t.Fatalf("Failed to hash user operation: %v", err)
+	}
+	client.version = EntryPointV07
+	if hashes, _ := client.userOpHashes(ctx, []entrypoint.PackedUserOperation{packed}); hashes[0] != expected {
+		t.Errorf("Expected the GetUserOpHash %s, got %s", expected.Hex(), hashes[0].Hex())
+	}
+}
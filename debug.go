@@ -0,0 +1,77 @@
+package aasdk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// BundlingMode is the bundling mode of the debug namespace.
+type BundlingMode string
+
+const (
+	// BundlingModeAuto bundles the user operations as they arrive.
+	BundlingModeAuto BundlingMode = "auto"
+	// BundlingModeManual only bundles on DebugSendBundleNow.
+	BundlingModeManual BundlingMode = "manual"
+)
+
+// ReputationEntry is the reputation of an entity, as used by the debug namespace.
+type ReputationEntry struct {
+	Address     common.Address `json:"address"`
+	OpsSeen     hexutil.Uint64 `json:"opsSeen"`
+	OpsIncluded hexutil.Uint64 `json:"opsIncluded"`
+	Status      string         `json:"status,omitempty"` // ok, throttled or banned, only set when dumped
+}
+
+// The debug_bundler_* methods are meant for tests against a bundler with the debug namespace enabled.
+
+// DebugClearState clears the mempool and the reputations of the bundler.
+func (c *Client) DebugClearState(ctx context.Context) error {
+	_, err := callBundler[any](ctx, c, "debug_bundler_clearState", nil)
+	return err
+}
+
+// DebugDumpMempool returns the user operations in the mempool of the entrypoint.
+func (c *Client) DebugDumpMempool(ctx context.Context) ([]*UserOperation, error) {
+	result, err := callBundler[[]*rpcUserOperation](ctx, c, "debug_bundler_dumpMempool", []any{c.config.Entrypoint})
+	if err != nil {
+		return nil, err
+	}
+	userOps := make([]*UserOperation, 0, len(result))
+	for _, userOp := range result {
+		if userOp == nil {
+			continue
+		}
+		decoded, err := userOp.toUserOperation()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding user operation: %v", err)
+		}
+		userOps = append(userOps, decoded)
+	}
+	return userOps, nil
+}
+
+// DebugSendBundleNow forces the bundler to send a bundle and returns the transaction hash.
+func (c *Client) DebugSendBundleNow(ctx context.Context) (common.Hash, error) {
+	return callBundler[common.Hash](ctx, c, "debug_bundler_sendBundleNow", nil)
+}
+
+// DebugSetBundlingMode sets the bundling mode of the bundler.
+func (c *Client) DebugSetBundlingMode(ctx context.Context, mode BundlingMode) error {
+	_, err := callBundler[any](ctx, c, "debug_bundler_setBundlingMode", []any{mode})
+	return err
+}
+
+// DebugSetReputation sets the reputations of the entities for the entrypoint.
+func (c *Client) DebugSetReputation(ctx context.Context, entries []ReputationEntry) error {
+	_, err := callBundler[any](ctx, c, "debug_bundler_setReputation", []any{entries, c.config.Entrypoint})
+	return err
+}
+
+// DebugDumpReputation returns the reputations of the entities for the entrypoint.
+func (c *Client) DebugDumpReputation(ctx context.Context) ([]ReputationEntry, error) {
+	return callBundler[[]ReputationEntry](ctx, c, "debug_bundler_dumpReputation", []any{c.config.Entrypoint})
+}
@@ -0,0 +1,131 @@
+package aasdk
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypoint"
+)
+
+// DecodedBundle is a handleOps, handleAtomicOps or handleAggregatedOps call to the entrypoint.
+type DecodedBundle struct {
+	Method      string // the entrypoint method called
+	Beneficiary common.Address
+	Ops         []DecodedUserOp
+}
+
+// DecodedUserOp is a user operation of a decoded bundle.
+type DecodedUserOp struct {
+	UserOp     *UserOperation
+	Packed     entrypoint.PackedUserOperation
+	Hash       common.Hash
+	Aggregator common.Address // only set by handleAggregatedOps
+	Index      int            // the index of the operation in the bundle, as reported by FailedOp
+}
+
+// DecodeHandleOps decodes the calldata of a handleOps, handleAtomicOps or handleAggregatedOps call
+// to the V0.7.0 entrypoint, the user operation hashes are computed with GetUserOpHash.
+func DecodeHandleOps(calldata []byte, entrypointAddr common.Address, chainId *big.Int) (*DecodedBundle, error) {
+	bundle, err := decodeHandleOps(calldata)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bundle.Ops {
+		op := &bundle.Ops[i]
+		if op.Hash, err = GetUserOpHash(&op.Packed, entrypointAddr, chainId); err != nil {
+			return nil, fmt.Errorf("error hashing user operation %d: %v", i, err)
+		}
+	}
+	return bundle, nil
+}
+
+// DecodeHandleOpsTx fetches the transaction and decodes its call to the entrypoint.
+// The user operation hashes are computed for the configured entrypoint version, V0.6.0 isn't supported.
+func (c *Client) DecodeHandleOpsTx(ctx context.Context, txHash common.Hash) (*DecodedBundle, error) {
+	tx, _, err := c.eth.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("error getting transaction %s: %v", txHash.Hex(), err)
+	}
+	if tx.To() == nil || *tx.To() != c.config.Entrypoint {
+		return nil, fmt.Errorf("transaction %s is not sent to the entrypoint", txHash.Hex())
+	}
+	return c.DecodeHandleOps(ctx, tx.Data())
+}
+
+// DecodeHandleOps decodes the calldata of a bundle to the entrypoint.
+// The user operation hashes are computed for the configured entrypoint version, V0.6.0 isn't supported.
+func (c *Client) DecodeHandleOps(ctx context.Context, calldata []byte) (*DecodedBundle, error) {
+	if c.version == EntryPointV06 {
+		return nil, fmt.Errorf("decoding bundles is not supported for entrypoint %s", c.version)
+	}
+	bundle, err := decodeHandleOps(calldata)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bundle.Ops {
+		op := &bundle.Ops[i]
+		if op.Hash, err = c.userOpHash(ctx, op.UserOp); err != nil {
+			return nil, fmt.Errorf("error hashing user operation %d: %v", i, err)
+		}
+	}
+	return bundle, nil
+}
+
+// decodeHandleOps decodes the calldata into unpacked user operations, without their hashes.
+func decodeHandleOps(calldata []byte) (*DecodedBundle, error) {
+	if len(calldata) < 4 {
+		return nil, fmt.Errorf("calldata too short")
+	}
+	entrypointABI, err := entrypoint.EntryPointMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("error getting entrypoint ABI: %v", err)
+	}
+	method, err := entrypointABI.MethodById(calldata[:4])
+	if err != nil {
+		return nil, fmt.Errorf("error finding entrypoint method: %v", err)
+	}
+	args, err := method.Inputs.Unpack(calldata[4:])
+	if err != nil {
+		return nil, fmt.Errorf("error unpacking %s calldata: %v", method.Name, err)
+	}
+
+	bundle := &DecodedBundle{Method: method.Name}
+	switch method.Name {
+	case "handleOps", "handleAtomicOps":
+		ops := *abi.ConvertType(args[0], new([]entrypoint.PackedUserOperation)).(*[]entrypoint.PackedUserOperation)
+		bundle.Beneficiary = *abi.ConvertType(args[1], new(common.Address)).(*common.Address)
+		if err := bundle.addOps(ops, common.Address{}); err != nil {
+			return nil, err
+		}
+	case "handleAggregatedOps":
+		perAggregator := *abi.ConvertType(args[0], new([]entrypoint.IEntryPointUserOpsPerAggregator)).(*[]entrypoint.IEntryPointUserOpsPerAggregator)
+		bundle.Beneficiary = *abi.ConvertType(args[1], new(common.Address)).(*common.Address)
+		for _, aggregated := range perAggregator {
+			if err := bundle.addOps(aggregated.UserOps, aggregated.Aggregator); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unexpected entrypoint method %s", method.Name)
+	}
+	return bundle, nil
+}
+
+func (b *DecodedBundle) addOps(ops []entrypoint.PackedUserOperation, aggregator common.Address) error {
+	for _, packed := range ops {
+		userOp, err := UnpackUserOperation(&packed)
+		if err != nil {
+			return fmt.Errorf("error unpacking user operation %d: %v", len(b.Ops), err)
+		}
+		b.Ops = append(b.Ops, DecodedUserOp{
+			UserOp:     userOp,
+			Packed:     packed,
+			Aggregator: aggregator,
+			Index:      len(b.Ops),
+		})
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package aasdk
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypoint"
+)
+
+func TestDecodeHandleOps(t *testing.T) {
+	entrypointABI, err := entrypoint.EntryPointMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to get entrypoint ABI: %v", err)
+	}
+	userOp := NewUserOpWithDefault(common.HexToAddress("0x01"), []byte{0x0a}, nil)
+	userOp.Nonce = big.NewInt(2)
+	userOp.Signature = []byte{0x04}
+	packed := PackUserOperation(userOp)
+	beneficiary := common.HexToAddress("0xbe")
+	calldata, err := entrypointABI.Pack("handleOps", []entrypoint.PackedUserOperation{packed, packed}, beneficiary)
+	if err != nil {
+		t.Fatalf("Failed to pack handleOps: %v", err)
+	}
+
+	entrypointAddr := common.HexToAddress("0xee")
+	chainId := big.NewInt(1)
+	bundle, err := DecodeHandleOps(calldata, entrypointAddr, chainId)
+	if err != nil {
+		t.Fatalf("Failed to decode handleOps: %v", err)
+	}
+	if bundle.Method != "handleOps" || bundle.Beneficiary != beneficiary || len(bundle.Ops) != 2 {
+		t.Fatalf("Unexpected bundle %+v", bundle)
+	}
+	hash, err := GetUserOpHash(&packed, entrypointAddr, chainId)
+	if err != nil {
+		t.Fatalf("Failed to hash user operation: %v", err)
+	}
+	op := bundle.Ops[1]
+	if op.Hash != hash || op.Index != 1 || op.UserOp.CallGasLimit.Cmp(userOp.CallGasLimit) != 0 {
+		t.Errorf("Unexpected user operation %+v", op)
+	}
+
+	if _, err := DecodeHandleOps([]byte{0x01, 0x02, 0x03, 0x04}, entrypointAddr, chainId); err == nil {
+		t.Errorf("Expected an error for an unknown method")
+	}
+}
+
+func TestDecodeHandleAggregatedOps(t *testing.T) {
+	entrypointABI, err := entrypoint.EntryPointMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to get entrypoint ABI: %v", err)
+	}
+	userOp := NewUserOpWithDefault(common.HexToAddress("0x01"), []byte{0x0a}, nil)
+	userOp.Nonce = big.NewInt(0)
+	first := PackUserOperation(userOp)
+	userOp.Sender = common.HexToAddress("0x02")
+	second := PackUserOperation(userOp)
+	aggregators := []common.Address{common.HexToAddress("0xa1"), common.HexToAddress("0xa2")}
+	beneficiary := common.HexToAddress("0xbe")
+	calldata, err := entrypointABI.Pack("handleAggregatedOps", []entrypoint.IEntryPointUserOpsPerAggregator{
+		{UserOps: []entrypoint.PackedUserOperation{first}, Aggregator: aggregators[0], Signature: []byte{0x01}},
+		{UserOps: []entrypoint.PackedUserOperation{first, second}, Aggregator: aggregators[1], Signature: []byte{0x02}},
+	}, beneficiary)
+	if err != nil {
+		t.Fatalf("Failed to pack handleAggregatedOps: %v", err)
+	}
+
+	entrypointAddr := common.HexToAddress("0xee")
+	chainId := big.NewInt(1)
+	bundle, err := DecodeHandleOps(calldata, entrypointAddr, chainId)
+	if err != nil {
+		t.Fatalf("Failed to decode handleAggregatedOps: %v", err)
+	}
+	if bundle.Method != "handleAggregatedOps" || bundle.Beneficiary != beneficiary || len(bundle.Ops) != 3 {
+		t.Fatalf("Unexpected bundle %+v", bundle)
+	}
+	// The operations are indexed across the aggregators, as by FailedOp
+	expected := []struct {
+		sender     common.Address
+		aggregator common.Address
+	}{
+		{first.Sender, aggregators[0]},
+		{first.Sender, aggregators[1]},
+		{second.Sender, aggregators[1]},
+	}
+	for i, op := range bundle.Ops {
+		if op.Index != i || op.UserOp.Sender != expected[i].sender || op.Aggregator != expected[i].aggregator {
+			t.Errorf("Unexpected user operation %d: %+v", i, op)
+		}
+	}
+	hash, err := GetUserOpHash(&second, entrypointAddr, chainId)
+	if err != nil {
+		t.Fatalf("Failed to hash user operation: %v", err)
+	}
+	if bundle.Ops[2].Hash != hash {
+		t.Errorf("Expected hash %s, got %s", hash.Hex(), bundle.Ops[2].Hash.Hex())
+	}
+}
+
+func TestDecodeHandleOpsUnrelated(t *testing.T) {
+	entrypointABI, err := entrypoint.EntryPointMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to get entrypoint ABI: %v", err)
+	}
+	// An entrypoint method other than a bundle
+	calldata, err := entrypointABI.Pack("depositTo", common.HexToAddress("0x01"))
+	if err != nil {
+		t.Fatalf("Failed to pack depositTo: %v", err)
+	}
+	if _, err := DecodeHandleOps(calldata, common.HexToAddress("0xee"), big.NewInt(1)); err == nil {
+		t.Errorf("Expected an error for depositTo")
+	}
+	// A selector of another contract, e.g. ERC-20 transfer
+	if _, err := DecodeHandleOps(common.FromHex("0xa9059cbb"), common.HexToAddress("0xee"), big.NewInt(1)); err == nil {
+		t.Errorf("Expected an error for an unrelated selector")
+	}
+	if _, err := DecodeHandleOps([]byte{0x01}, common.HexToAddress("0xee"), big.NewInt(1)); err == nil {
+		t.Errorf("Expected an error for short calldata")
+	}
+}
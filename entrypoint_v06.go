@@ -0,0 +1,227 @@
+package aasdk
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypointv6"
+)
+
+// senderNonceSelector is the selector of senderNonce(address) of the V0.6.0 verifying paymaster.
+var senderNonceSelector = crypto.Keccak256([]byte("senderNonce(address)"))[:4]
+
+// PackUserOperationV06 converts a user operation into the EntryPoint V0.6.0 UserOperation.
+// The paymaster and its data are concatenated into PaymasterAndData, the paymaster gas limits are unused.
+// It panics if the user operation is nil.
+func PackUserOperationV06(userOp *UserOperation) entrypointv6.UserOperation {
+	if userOp == nil {
+		panic("nil user operation")
+	}
+	paymasterAndData := []byte{}
+	if userOp.Paymaster != (common.Address{}) {
+		paymasterAndData = PackPaymasterAndDataV06(userOp.Paymaster, userOp.PaymasterData)
+	}
+	initCode := userOp.InitCode
+	if initCode == nil {
+		initCode = []byte{}
+	}
+	return entrypointv6.UserOperation{
+		Sender:               userOp.Sender,
+		Nonce:                userOp.Nonce,
+		InitCode:             initCode,
+		CallData:             userOp.CallData,
+		CallGasLimit:         userOp.CallGasLimit,
+		VerificationGasLimit: userOp.VerificationGasLimit,
+		PreVerificationGas:   userOp.PreVerificationGas,
+		MaxFeePerGas:         userOp.MaxFeePerGas,
+		MaxPriorityFeePerGas: userOp.MaxPriorityFeePerGas,
+		PaymasterAndData:     paymasterAndData,
+		Signature:            userOp.Signature,
+	}
+}
+
+// GetUserOpHashV06 returns the EntryPoint V0.6.0 hash of the user operation.
+func GetUserOpHashV06(userOp *entrypointv6.UserOperation, entrypoint common.Address, chainId *big.Int) (common.Hash, error) {
+	hashed, err := HashedUserOpV06(userOp)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hashArgs := abi.Arguments{
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, // userOp.hash
+		{Type: abi.Type{T: abi.AddressTy}},              // entrypoint address
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      // chainID
+	}
+	packedHash, err := hashArgs.Pack(hashed, entrypoint, chainId)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(packedHash), nil
+}
+
+// HashedUserOpV06 returns the hash of the EntryPoint V0.6.0 user operation fields, without the signature.
+func HashedUserOpV06(userOp *entrypointv6.UserOperation) (common.Hash, error) {
+	arguments := abi.Arguments{
+		{Type: abi.Type{T: abi.AddressTy}},              // sender
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      // nonce
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, // hashInitCode
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, // hashCallData
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      // callGasLimit
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      // verificationGasLimit
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      // preVerificationGas
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      // maxFeePerGas
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      // maxPriorityFeePerGas
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, // hashPaymasterAndData
+	}
+
+	packed, err := arguments.Pack(
+		userOp.Sender,
+		userOp.Nonce,
+		crypto.Keccak256Hash(userOp.InitCode),
+		crypto.Keccak256Hash(userOp.CallData),
+		userOp.CallGasLimit,
+		userOp.VerificationGasLimit,
+		userOp.PreVerificationGas,
+		userOp.MaxFeePerGas,
+		userOp.MaxPriorityFeePerGas,
+		crypto.Keccak256Hash(userOp.PaymasterAndData),
+	)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(packed), nil
+}
+
+// GetPaymasterHashV06 returns the hash to sign for a user operation with the V0.6.0 verifying paymaster.
+// The sender nonce is the senderNonce of the sender in the paymaster, see GetPaymasterSenderNonceV06.
+func GetPaymasterHashV06(
+	userOp *entrypointv6.UserOperation,
+	paymaster common.Address,
+	chainId *big.Int,
+	senderNonce *big.Int,
+	validUntil *big.Int,
+	validAfter *big.Int,
+) (common.Hash, error) {
+	args := abi.Arguments{
+		{Type: abi.Type{T: abi.AddressTy}},              //	sender
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      //	nonce
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, //	initCode
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, //	callData
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      //	callGasLimit
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      //	verificationGasLimit
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      //	preVerificationGas
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      //	maxFeePerGas
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      //	maxPriorityFeePerGas
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      //	chainId
+		{Type: abi.Type{T: abi.AddressTy}},              //	paymaster's address
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      //	senderNonce
+		{Type: abi.Type{T: abi.UintTy, Size: 48}},       //	validUntil
+		{Type: abi.Type{T: abi.UintTy, Size: 48}},       //	validAfter
+	}
+
+	packed, err := args.Pack(
+		userOp.Sender,
+		userOp.Nonce,
+		crypto.Keccak256Hash(userOp.InitCode),
+		crypto.Keccak256Hash(userOp.CallData),
+		userOp.CallGasLimit,
+		userOp.VerificationGasLimit,
+		userOp.PreVerificationGas,
+		userOp.MaxFeePerGas,
+		userOp.MaxPriorityFeePerGas,
+		chainId,
+		paymaster,
+		senderNonce,
+		validUntil,
+		validAfter,
+	)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("pack error in GetPaymasterHashV06: %v", err)
+	}
+
+	return crypto.Keccak256Hash(packed), nil
+}
+
+// GetPaymasterSenderNonceV06 returns the senderNonce of the sender in the V0.6.0 verifying paymaster.
+// The paymaster increments it with every sponsored user operation, so its signatures can't be replayed.
+func GetPaymasterSenderNonceV06(ctx context.Context, caller bind.ContractCaller, paymaster common.Address, sender common.Address) (*big.Int, error) {
+	data := append(append([]byte{}, senderNonceSelector...), common.LeftPadBytes(sender.Bytes(), 32)...)
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &paymaster, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error calling senderNonce: %v", err)
+	}
+	if len(result) != 32 {
+		return nil, fmt.Errorf("invalid senderNonce result: %x", result)
+	}
+	return new(big.Int).SetBytes(result), nil
+}
+
+// PackPaymasterAndDataV06 constructs the V0.6.0 paymasterAndData field.
+// Unlike V0.7.0, it doesn't carry the paymaster gas limits.
+func PackPaymasterAndDataV06(paymaster common.Address, data []byte) []byte {
+	result := make([]byte, 0, len(paymaster)+len(data))
+	result = append(result, paymaster[:]...) // 20 bytes
+	result = append(result, data...)         // variable length
+	return result
+}
+
+// ToBodyV06 converts the UserOperation to the EntryPoint V0.6.0 RPC format.
+// Unlike ToBody, the empty byte fields are kept as V0.6.0 bundlers require them.
+func (u *UserOperation) ToBodyV06() map[string]any {
+	packed := PackUserOperationV06(u)
+	body := make(map[string]any)
+	body["sender"] = u.Sender.Hex()
+	if u.Nonce != nil {
+		body["nonce"] = "0x" + u.Nonce.Text(16)
+	}
+	body["initCode"] = "0x" + hex.EncodeToString(packed.InitCode)
+	body["callData"] = "0x" + hex.EncodeToString(u.CallData)
+	if u.CallGasLimit != nil {
+		body["callGasLimit"] = "0x" + u.CallGasLimit.Text(16)
+	}
+	if u.VerificationGasLimit != nil {
+		body["verificationGasLimit"] = "0x" + u.VerificationGasLimit.Text(16)
+	}
+	if u.PreVerificationGas != nil {
+		body["preVerificationGas"] = "0x" + u.PreVerificationGas.Text(16)
+	}
+	if u.MaxFeePerGas != nil {
+		body["maxFeePerGas"] = "0x" + u.MaxFeePerGas.Text(16)
+	}
+	if u.MaxPriorityFeePerGas != nil {
+		body["maxPriorityFeePerGas"] = "0x" + u.MaxPriorityFeePerGas.Text(16)
+	}
+	body["paymasterAndData"] = "0x" + hex.EncodeToString(packed.PaymasterAndData)
+	body["signature"] = "0x" + hex.EncodeToString(u.Signature)
+	return body
+}
+
+// HandleOpsV06 handles the user operations by calling the EntryPoint V0.6.0 contract directly.
+// It returns the user operation hashes, as in the UserOperationEvent, and the transaction hash.
+func (c *Client) HandleOpsV06(ctx context.Context, ops []entrypointv6.UserOperation) ([]common.Hash, common.Hash, error) {
+	if c.entrypointV06 == nil {
+		return []common.Hash{}, common.Hash{}, fmt.Errorf("entrypoint %s is not V0.6.0", c.config.Entrypoint.Hex())
+	}
+	var opHashes []common.Hash
+	for _, op := range ops {
+		hashed, err := GetUserOpHashV06(&op, c.config.Entrypoint, c.chainId)
+		if err != nil {
+			return []common.Hash{}, common.Hash{}, fmt.Errorf("error hashing user operation: %v", err)
+		}
+		opHashes = append(opHashes, hashed)
+	}
+	txHash, err := c.sendWithExecutor(ctx, "handling ops", func(opts *bind.TransactOpts, beneficiary common.Address) (*types.Transaction, error) {
+		return c.entrypointV06.HandleOps(opts, ops, beneficiary)
+	})
+	if err != nil {
+		return []common.Hash{}, common.Hash{}, err
+	}
+	return opHashes, txHash, nil
+}
@@ -0,0 +1,247 @@
+package aasdk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// userOpV06 returns a V0.6.0 user operation of the hash vectors.
+func userOpV06() *UserOperation {
+	return &UserOperation{
+		Sender:               common.HexToAddress("0x1306b01bC3e4AD202612D3843387e94737673F53"),
+		Nonce:                big.NewInt(7),
+		InitCode:             common.FromHex("0x9406cc6185a346906296840746125a0e449764545fbfb9cf000000000000000000000000"),
+		CallData:             common.FromHex("0xb61d27f6"),
+		CallGasLimit:         big.NewInt(100000),
+		VerificationGasLimit: big.NewInt(200000),
+		PreVerificationGas:   big.NewInt(50000),
+		MaxFeePerGas:         big.NewInt(3000000000),
+		MaxPriorityFeePerGas: big.NewInt(1000000000),
+	}
+}
+
+func TestPackUserOperationV06(t *testing.T) {
+	userOp := userOpV06()
+	userOp.InitCode = nil
+	packed := PackUserOperationV06(userOp)
+	if packed.InitCode == nil || len(packed.InitCode) != 0 {
+		t.Errorf("Expected empty init code, got %x", packed.InitCode)
+	}
+	if packed.PaymasterAndData == nil || len(packed.PaymasterAndData) != 0 {
+		t.Errorf("Expected empty paymasterAndData without a paymaster, got %x", packed.PaymasterAndData)
+	}
+
+	// The paymaster gas limits are dropped
+	userOp.Paymaster = common.HexToAddress("0xaa")
+	userOp.PaymasterData = []byte{0x01, 0x02}
+	userOp.PaymasterVerificationGasLimit = big.NewInt(1000)
+	userOp.PaymasterPostOpGasLimit = big.NewInt(2000)
+	packed = PackUserOperationV06(userOp)
+	expected := append(common.HexToAddress("0xaa").Bytes(), 0x01, 0x02)
+	if !bytes.Equal(packed.PaymasterAndData, expected) {
+		t.Errorf("Expected paymasterAndData %x, got %x", expected, packed.PaymasterAndData)
+	}
+	if packed.Sender != userOp.Sender || packed.Nonce.Cmp(userOp.Nonce) != 0 || packed.CallGasLimit.Cmp(userOp.CallGasLimit) != 0 {
+		t.Errorf("Unexpected packed user operation %+v", packed)
+	}
+}
+
+// words concatenates the values as 32-byte words, as abi.encode does for static types.
+func words(values ...any) []byte {
+	var encoded []byte
+	for _, value := range values {
+		switch v := value.(type) {
+		case common.Address:
+			encoded = append(encoded, common.LeftPadBytes(v.Bytes(), 32)...)
+		case common.Hash:
+			encoded = append(encoded, v.Bytes()...)
+		case int64:
+			encoded = append(encoded, common.LeftPadBytes(big.NewInt(v).Bytes(), 32)...)
+		case *big.Int:
+			encoded = append(encoded, common.LeftPadBytes(v.Bytes(), 32)...)
+		default:
+			panic(fmt.Sprintf("unexpected word %T", value))
+		}
+	}
+	return encoded
+}
+
+// The expected hashes are built word by word in the order of UserOperationLib.pack and
+// EntryPoint.getUserOpHash of the V0.6.0 contracts:
+// keccak256(abi.encode(keccak256(abi.encode(sender, nonce, keccak256(initCode), keccak256(callData), callGasLimit,
+// verificationGasLimit, preVerificationGas, maxFeePerGas, maxPriorityFeePerGas, keccak256(paymasterAndData))),
+// entryPoint, chainid)).
+func TestGetUserOpHashV06(t *testing.T) {
+	entrypointAddr := common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+	chainId := big.NewInt(11155111)
+	expectedHash := func(userOp *UserOperation, paymasterAndData []byte) common.Hash {
+		hashed := crypto.Keccak256Hash(words(
+			userOp.Sender,
+			userOp.Nonce,
+			crypto.Keccak256Hash(userOp.InitCode),
+			crypto.Keccak256Hash(userOp.CallData),
+			userOp.CallGasLimit,
+			userOp.VerificationGasLimit,
+			userOp.PreVerificationGas,
+			userOp.MaxFeePerGas,
+			userOp.MaxPriorityFeePerGas,
+			crypto.Keccak256Hash(paymasterAndData),
+		))
+		return crypto.Keccak256Hash(words(hashed, entrypointAddr, chainId))
+	}
+
+	userOp := userOpV06()
+	packed := PackUserOperationV06(userOp)
+	hash, err := GetUserOpHashV06(&packed, entrypointAddr, chainId)
+	if err != nil {
+		t.Fatalf("Failed to hash user operation: %v", err)
+	}
+	if expected := expectedHash(userOp, nil); hash != expected {
+		t.Errorf("Expected hash %s, got %s", expected.Hex(), hash.Hex())
+	}
+
+	userOp.Paymaster = common.HexToAddress("0xaa")
+	userOp.PaymasterData = append(make([]byte, 64), bytes.Repeat([]byte{0x01}, 65)...)
+	packed = PackUserOperationV06(userOp)
+	hash, err = GetUserOpHashV06(&packed, entrypointAddr, chainId)
+	if err != nil {
+		t.Fatalf("Failed to hash user operation: %v", err)
+	}
+	if expected := expectedHash(userOp, append(userOp.Paymaster.Bytes(), userOp.PaymasterData...)); hash != expected {
+		t.Errorf("Expected hash with paymaster %s, got %s", expected.Hex(), hash.Hex())
+	}
+
+	// The signature isn't hashed
+	packed.Signature = []byte{0x01}
+	if signed, err := GetUserOpHashV06(&packed, entrypointAddr, chainId); err != nil || signed != hash {
+		t.Errorf("Expected the hash not to depend on the signature, got %s, error %v", signed.Hex(), err)
+	}
+}
+
+// The expected hash is built word by word in the order of VerifyingPaymaster.getHash of the V0.6.0 contracts:
+// keccak256(abi.encode(sender, nonce, keccak256(initCode), keccak256(callData), callGasLimit, verificationGasLimit,
+// preVerificationGas, maxFeePerGas, maxPriorityFeePerGas, block.chainid, address(this), senderNonce[sender],
+// validUntil, validAfter)).
+func TestGetPaymasterHashV06(t *testing.T) {
+	paymasterAddr := common.HexToAddress("0xaa")
+	chainId := big.NewInt(11155111)
+	userOp := userOpV06()
+	expected := crypto.Keccak256Hash(words(
+		userOp.Sender,
+		userOp.Nonce,
+		crypto.Keccak256Hash(userOp.InitCode),
+		crypto.Keccak256Hash(userOp.CallData),
+		userOp.CallGasLimit,
+		userOp.VerificationGasLimit,
+		userOp.PreVerificationGas,
+		userOp.MaxFeePerGas,
+		userOp.MaxPriorityFeePerGas,
+		chainId,
+		paymasterAddr,
+		int64(3),
+		int64(1700000000),
+		int64(1600000000),
+	))
+
+	packed := PackUserOperationV06(userOp)
+	hash, err := GetPaymasterHashV06(&packed, paymasterAddr, chainId, big.NewInt(3), big.NewInt(1700000000), big.NewInt(1600000000))
+	if err != nil {
+		t.Fatalf("Failed to hash paymaster data: %v", err)
+	}
+	if hash != expected {
+		t.Errorf("Expected hash %s, got %s", expected.Hex(), hash.Hex())
+	}
+
+	// The sender nonce is signed over
+	if next, err := GetPaymasterHashV06(&packed, paymasterAddr, chainId, big.NewInt(4), big.NewInt(1700000000), big.NewInt(1600000000)); err != nil || next == hash {
+		t.Errorf("Expected the hash to depend on the sender nonce, got %s, error %v", next.Hex(), err)
+	}
+
+	// The paymaster data isn't hashed
+	userOp.Paymaster = paymasterAddr
+	userOp.PaymasterData = []byte{0x01}
+	packed = PackUserOperationV06(userOp)
+	if withData, err := GetPaymasterHashV06(&packed, paymasterAddr, chainId, big.NewInt(3), big.NewInt(1700000000), big.NewInt(1600000000)); err != nil || withData != hash {
+		t.Errorf("Expected the hash not to depend on the paymaster data, got %s, error %v", withData.Hex(), err)
+	}
+}
+
+// senderNonceCaller answers senderNonce calls with the nonce of the sender, and records the calls.
+type senderNonceCaller struct {
+	nonces map[common.Address]int64
+	calls  []ethereum.CallMsg
+}
+
+func (c *senderNonceCaller) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{0x60}, nil
+}
+
+func (c *senderNonceCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	c.calls = append(c.calls, call)
+	if len(call.Data) != 36 || !bytes.Equal(call.Data[:4], senderNonceSelector) {
+		return nil, fmt.Errorf("unexpected call %x", call.Data)
+	}
+	return common.LeftPadBytes(big.NewInt(c.nonces[common.BytesToAddress(call.Data[4:])]).Bytes(), 32), nil
+}
+
+func TestGetPaymasterSenderNonceV06(t *testing.T) {
+	sender := common.HexToAddress("0x01")
+	caller := &senderNonceCaller{nonces: map[common.Address]int64{sender: 5}}
+	nonce, err := GetPaymasterSenderNonceV06(context.Background(), caller, common.HexToAddress("0xaa"), sender)
+	if err != nil {
+		t.Fatalf("Failed to get sender nonce: %v", err)
+	}
+	if nonce.Int64() != 5 {
+		t.Errorf("Expected sender nonce 5, got %s", nonce)
+	}
+	// senderNonce(address) is called on the paymaster
+	if call := caller.calls[0]; *call.To != common.HexToAddress("0xaa") || common.Bytes2Hex(call.Data[:4]) != "9c90b443" {
+		t.Errorf("Unexpected call %+v", call)
+	}
+}
+
+func TestToBodyV06(t *testing.T) {
+	userOp := userOpV06()
+	userOp.InitCode = nil
+	body := userOp.ToBodyV06()
+
+	expected := map[string]string{
+		"sender":               userOp.Sender.Hex(),
+		"nonce":                "0x7",
+		"initCode":             "0x",
+		"callData":             "0xb61d27f6",
+		"callGasLimit":         "0x186a0",
+		"verificationGasLimit": "0x30d40",
+		"preVerificationGas":   "0xc350",
+		"maxFeePerGas":         "0xb2d05e00",
+		"maxPriorityFeePerGas": "0x3b9aca00",
+		"paymasterAndData":     "0x",
+		"signature":            "0x",
+	}
+	if len(body) != len(expected) {
+		t.Errorf("Expected %d fields, got %v", len(expected), body)
+	}
+	for field, value := range expected {
+		if body[field] != value {
+			t.Errorf("Expected %s %s, got %v", field, value, body[field])
+		}
+	}
+
+	// The paymaster is packed into paymasterAndData
+	userOp.Paymaster = common.HexToAddress("0xaa")
+	userOp.PaymasterData = []byte{0x01}
+	body = userOp.ToBodyV06()
+	if body["paymasterAndData"] != "0x00000000000000000000000000000000000000aa01" {
+		t.Errorf("Unexpected paymasterAndData %v", body["paymasterAndData"])
+	}
+	if _, ok := body["paymaster"]; ok {
+		t.Errorf("Expected no V0.7.0 paymaster field")
+	}
+}
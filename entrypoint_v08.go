@@ -0,0 +1,302 @@
+package aasdk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypoint"
+)
+
+const (
+	// The EIP-712 domain name and version of EntryPoint V0.8.0.
+	EntryPointV08DomainName    = "ERC4337"
+	EntryPointV08DomainVersion = "1"
+	// eip7702AuthMagic prefixes the RLP encoded authorization tuple before hashing.
+	eip7702AuthMagic = 0x05
+)
+
+var (
+	// Eip7702InitCodeMarker is the initCode prefix of an EIP-7702 delegated sender in EntryPoint V0.8.0.
+	// It's 0x7702 padded to 20 bytes, the rest of the initCode is the calldata to initialize the account.
+	Eip7702InitCodeMarker = common.Address{0x77, 0x02}
+
+	eip712DomainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	packedUserOpTypeHash = crypto.Keccak256Hash([]byte("PackedUserOperation(address sender,uint256 nonce,bytes initCode,bytes callData,bytes32 accountGasLimits,uint256 preVerificationGas,bytes32 gasFees,bytes paymasterAndData)"))
+	// eip7702DelegationPrefix prefixes the code of a delegated account, followed by the delegate address.
+	eip7702DelegationPrefix = []byte{0xef, 0x01, 0x00}
+)
+
+// Eip7702Auth is a signed EIP-7702 authorization tuple, delegating the signer's EOA to a contract.
+type Eip7702Auth struct {
+	ChainId *big.Int       `json:"chainId"`
+	Address common.Address `json:"address"`
+	Nonce   uint64         `json:"nonce"`
+	YParity uint8          `json:"yParity"`
+	R       *big.Int       `json:"r"`
+	S       *big.Int       `json:"s"`
+}
+
+// ToBody converts the authorization to the bundler RPC format.
+func (a *Eip7702Auth) ToBody() map[string]string {
+	return map[string]string{
+		"chainId": "0x" + a.ChainId.Text(16),
+		"address": a.Address.Hex(),
+		"nonce":   "0x" + new(big.Int).SetUint64(a.Nonce).Text(16),
+		"yParity": "0x" + big.NewInt(int64(a.YParity)).Text(16),
+		"r":       "0x" + a.R.Text(16),
+		"s":       "0x" + a.S.Text(16),
+	}
+}
+
+// ToBodyV08 converts the UserOperation to the EntryPoint V0.8.0 RPC format.
+// Unlike ToBody, it carries the EIP-7702 authorization of the sender, if any.
+func (u *UserOperation) ToBodyV08() map[string]any {
+	body := make(map[string]any)
+	for field, value := range u.ToBody() {
+		body[field] = value
+	}
+	if u.Eip7702Auth != nil {
+		body["eip7702Auth"] = u.Eip7702Auth.ToBody()
+	}
+	return body
+}
+
+// Authority recovers the address of the EOA that signed the authorization.
+func (a *Eip7702Auth) Authority() (common.Address, error) {
+	hash, err := Eip7702AuthHash(a.ChainId, a.Address, a.Nonce)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if a.YParity > 1 || a.R == nil || a.S == nil {
+		return common.Address{}, fmt.Errorf("invalid authorization signature")
+	}
+	sig := make([]byte, 65)
+	a.R.FillBytes(sig[:32])
+	a.S.FillBytes(sig[32:64])
+	sig[64] = a.YParity
+	pub, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("error recovering authority: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// Eip7702AuthHash returns the hash to sign for an EIP-7702 authorization,
+// keccak256(0x05 || rlp([chainId, address, nonce])).
+func Eip7702AuthHash(chainId *big.Int, delegate common.Address, nonce uint64) (common.Hash, error) {
+	encoded, err := rlp.EncodeToBytes([]any{chainId, delegate, nonce})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("error encoding authorization: %v", err)
+	}
+	return crypto.Keccak256Hash([]byte{eip7702AuthMagic}, encoded), nil
+}
+
+// SignEip7702Auth signs an EIP-7702 authorization delegating the signer's EOA to the delegate.
+// The nonce must be the current nonce of the EOA, as it's the bundler that sends the transaction.
+// The signer must implement AuthorizationSigner or sign raw hashes.
+func SignEip7702Auth(ctx context.Context, signer Signer, chainId *big.Int, delegate common.Address, nonce uint64) (*Eip7702Auth, error) {
+	var sig []byte
+	if authSigner, ok := signer.(AuthorizationSigner); ok {
+		var err error
+		if sig, err = authSigner.SignAuthorization(ctx, chainId, delegate, nonce); err != nil {
+			return nil, fmt.Errorf("error signing authorization: %w", err)
+		}
+	} else {
+		hash, err := Eip7702AuthHash(chainId, delegate, nonce)
+		if err != nil {
+			return nil, err
+		}
+		if sig, err = signer.SignHash(ctx, hash.Bytes()); err != nil {
+			return nil, fmt.Errorf("error signing authorization: %w", err)
+		}
+	}
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("invalid signature length: %d", len(sig))
+	}
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	return &Eip7702Auth{
+		ChainId: new(big.Int).Set(chainId),
+		Address: delegate,
+		Nonce:   nonce,
+		YParity: v,
+		R:       new(big.Int).SetBytes(sig[:32]),
+		S:       new(big.Int).SetBytes(sig[32:64]),
+	}, nil
+}
+
+// IsEip7702InitCode checks if the initCode starts with the EIP-7702 marker.
+func IsEip7702InitCode(initCode []byte) bool {
+	if len(initCode) < 2 {
+		return false
+	}
+	start := common.RightPadBytes(initCode[:min(len(initCode), common.AddressLength)], common.AddressLength)
+	return bytes.Equal(start, Eip7702InitCodeMarker[:])
+}
+
+// GetEip7702Delegate returns the contract the account delegates to with EIP-7702.
+// It returns false if the account has no delegation.
+func GetEip7702Delegate(ctx context.Context, client *ethclient.Client, account common.Address) (common.Address, bool, error) {
+	code, err := client.CodeAt(ctx, account, nil)
+	if err != nil {
+		return common.Address{}, false, err
+	}
+	if len(code) != len(eip7702DelegationPrefix)+common.AddressLength || !bytes.HasPrefix(code, eip7702DelegationPrefix) {
+		return common.Address{}, false, nil
+	}
+	return common.BytesToAddress(code[len(eip7702DelegationPrefix):]), true, nil
+}
+
+// GetUserOpHashV08 returns the EntryPoint V0.8.0 hash of the user operation, an EIP-712 typed data hash.
+// The delegate is the EIP-7702 delegate of the sender, it's only used when the initCode starts with Eip7702InitCodeMarker.
+func GetUserOpHashV08(packed *entrypoint.PackedUserOperation, entrypoint common.Address, chainId *big.Int, delegate common.Address) (common.Hash, error) {
+	hashInitCode := crypto.Keccak256Hash(packed.InitCode)
+	if IsEip7702InitCode(packed.InitCode) {
+		if len(packed.InitCode) <= common.AddressLength {
+			hashInitCode = crypto.Keccak256Hash(delegate.Bytes())
+		} else {
+			hashInitCode = crypto.Keccak256Hash(delegate.Bytes(), packed.InitCode[common.AddressLength:])
+		}
+	}
+
+	structArgs := abi.Arguments{
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, // typeHash
+		{Type: abi.Type{T: abi.AddressTy}},              // sender
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      // nonce
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, // hashInitCode
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, // hashCallData
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, // accountGasLimits
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      // preVerificationGas
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, // gasFees
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, // hashPaymasterAndData
+	}
+	structPacked, err := structArgs.Pack(
+		packedUserOpTypeHash,
+		packed.Sender,
+		packed.Nonce,
+		hashInitCode,
+		crypto.Keccak256Hash(packed.CallData),
+		packed.AccountGasLimits,
+		packed.PreVerificationGas,
+		packed.GasFees,
+		crypto.Keccak256Hash(packed.PaymasterAndData),
+	)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	domainArgs := abi.Arguments{
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, // typeHash
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, // name
+		{Type: abi.Type{T: abi.FixedBytesTy, Size: 32}}, // version
+		{Type: abi.Type{T: abi.UintTy, Size: 256}},      // chainId
+		{Type: abi.Type{T: abi.AddressTy}},              // verifyingContract
+	}
+	domainPacked, err := domainArgs.Pack(
+		eip712DomainTypeHash,
+		crypto.Keccak256Hash([]byte(EntryPointV08DomainName)),
+		crypto.Keccak256Hash([]byte(EntryPointV08DomainVersion)),
+		chainId,
+		entrypoint,
+	)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return crypto.Keccak256Hash(
+		[]byte{0x19, 0x01},
+		crypto.Keccak256(domainPacked),
+		crypto.Keccak256(structPacked),
+	), nil
+}
+
+// UserOpTypedDataV08 returns the EIP-712 typed data of the user operation signed for EntryPoint V0.8.0,
+// its hash is GetUserOpHashV08. The delegate replaces the EIP-7702 marker of the initCode, as for GetUserOpHashV08.
+func UserOpTypedDataV08(packed *entrypoint.PackedUserOperation, entrypoint common.Address, chainId *big.Int, delegate common.Address) apitypes.TypedData {
+	initCode := packed.InitCode
+	if IsEip7702InitCode(initCode) {
+		initCode = delegate.Bytes()
+		if len(packed.InitCode) > common.AddressLength {
+			initCode = append(initCode, packed.InitCode[common.AddressLength:]...)
+		}
+	}
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"PackedUserOperation": {
+				{Name: "sender", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "initCode", Type: "bytes"},
+				{Name: "callData", Type: "bytes"},
+				{Name: "accountGasLimits", Type: "bytes32"},
+				{Name: "preVerificationGas", Type: "uint256"},
+				{Name: "gasFees", Type: "bytes32"},
+				{Name: "paymasterAndData", Type: "bytes"},
+			},
+		},
+		PrimaryType: "PackedUserOperation",
+		Domain: apitypes.TypedDataDomain{
+			Name:              EntryPointV08DomainName,
+			Version:           EntryPointV08DomainVersion,
+			ChainId:           (*math.HexOrDecimal256)(chainId),
+			VerifyingContract: entrypoint.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"sender":             packed.Sender.Hex(),
+			"nonce":              hexutil.EncodeBig(packed.Nonce),
+			"initCode":           hexutil.Encode(initCode),
+			"callData":           hexutil.Encode(packed.CallData),
+			"accountGasLimits":   hexutil.Encode(packed.AccountGasLimits[:]),
+			"preVerificationGas": hexutil.EncodeBig(packed.PreVerificationGas),
+			"gasFees":            hexutil.Encode(packed.GasFees[:]),
+			"paymasterAndData":   hexutil.Encode(packed.PaymasterAndData),
+		},
+	}
+}
+
+// Authorize7702 signs an EIP-7702 authorization delegating the signer's EOA to the delegate,
+// using the current nonce of the EOA.
+func (c *Client) Authorize7702(ctx context.Context, signer Signer, delegate common.Address) (*Eip7702Auth, error) {
+	nonce, err := c.eth.PendingNonceAt(ctx, signer.Address())
+	if err != nil {
+		return nil, fmt.Errorf("error getting account nonce: %v", err)
+	}
+	return SignEip7702Auth(ctx, signer, c.chainId, delegate, nonce)
+}
+
+// fill7702Auth attaches an authorization to the user operation unless the sender already delegates to the configured delegate.
+func (c *Client) fill7702Auth(ctx context.Context, userOp *UserOperation, signer Signer) error {
+	if userOp.Eip7702Auth != nil {
+		return nil
+	}
+	delegate, ok, err := GetEip7702Delegate(ctx, c.eth, userOp.Sender)
+	if err != nil {
+		return fmt.Errorf("error getting account delegate: %v", err)
+	}
+	if ok && delegate == c.config.Eip7702Delegate {
+		return nil
+	}
+	auth, err := c.Authorize7702(ctx, signer, c.config.Eip7702Delegate)
+	if err != nil {
+		return err
+	}
+	userOp.Eip7702Auth = auth
+	return nil
+}
@@ -0,0 +1,246 @@
+package aasdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+func TestSignEip7702Auth(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer := NewPrivateKeySigner(key)
+	delegate := common.HexToAddress("0xe6Cae83BdE06E4c305530e199D7217f42808555B")
+
+	auth, err := SignEip7702Auth(context.Background(), signer, big.NewInt(1), delegate, 7)
+	if err != nil {
+		t.Fatalf("Failed to sign authorization: %v", err)
+	}
+	if auth.YParity > 1 {
+		t.Errorf("Expected yParity to be 0 or 1, got %d", auth.YParity)
+	}
+	authority, err := auth.Authority()
+	if err != nil {
+		t.Fatalf("Failed to recover authority: %v", err)
+	}
+	if authority != signer.Address() {
+		t.Errorf("Expected authority %s, got %s", signer.Address().Hex(), authority.Hex())
+	}
+
+	// Tampering with the tuple changes the recovered authority
+	auth.Nonce++
+	if authority, err := auth.Authority(); err == nil && authority == signer.Address() {
+		t.Errorf("Expected tampered authorization not to recover the signer")
+	}
+}
+
+func TestIsEip7702InitCode(t *testing.T) {
+	tests := []struct {
+		initCode []byte
+		expected bool
+	}{
+		{nil, false},
+		{[]byte{0x77}, false},
+		{[]byte{0x77, 0x02}, true},
+		{Eip7702InitCodeMarker.Bytes(), true},
+		{append(Eip7702InitCodeMarker.Bytes(), 0x01, 0x02), true},
+		{common.HexToAddress("0x7702").Bytes(), false},
+	}
+	for _, test := range tests {
+		if got := IsEip7702InitCode(test.initCode); got != test.expected {
+			t.Errorf("IsEip7702InitCode(%x): expected %v, got %v", test.initCode, test.expected, got)
+		}
+	}
+}
+
+// The expected hashes are the keccak256 of the EIP-712 encoding of the V0.8.0 contracts.
+func TestGetUserOpHashV08(t *testing.T) {
+	entrypointAddr := common.HexToAddress("0x4337084D9E255Ff0702461CF8895CE9E3b5Ff108")
+	delegate := common.HexToAddress("0xe6Cae83BdE06E4c305530e199D7217f42808555B")
+	chainId := big.NewInt(11155111)
+	userOp := &UserOperation{
+		Sender:               common.HexToAddress("0x1306b01bC3e4AD202612D3843387e94737673F53"),
+		Nonce:                big.NewInt(7),
+		CallData:             common.FromHex("0xb61d27f6"),
+		CallGasLimit:         big.NewInt(100000),
+		VerificationGasLimit: big.NewInt(200000),
+		PreVerificationGas:   big.NewInt(50000),
+		MaxFeePerGas:         big.NewInt(3000000000),
+		MaxPriorityFeePerGas: big.NewInt(1000000000),
+	}
+
+	tests := []struct {
+		initCode []byte
+		expected common.Hash
+	}{
+		{common.FromHex("0x9406cc6185a346906296840746125a0e449764545fbfb9cf000000000000000000000000"), common.HexToHash("0x56879183a5417be312cfdda9beffe5c44963649327b64adafe78ad57774ce1e7")},
+		// The marker is replaced by the delegate
+		{Eip7702InitCodeMarker.Bytes(), common.HexToHash("0x21b1d7668831473f234cfd74e7b0c2894a91f02a0f980afb08bc2f992ed36c06")},
+		{append(Eip7702InitCodeMarker.Bytes(), 0xab, 0xcd), common.HexToHash("0x5c982d2b61bfe3b0da877b7b524eac80bc49eb8047474e952c5d0fbb0f7a5c36")},
+	}
+	for _, test := range tests {
+		userOp.InitCode = test.initCode
+		packed := PackUserOperation(userOp)
+		hash, err := GetUserOpHashV08(&packed, entrypointAddr, chainId, delegate)
+		if err != nil {
+			t.Fatalf("Failed to hash user operation: %v", err)
+		}
+		if hash != test.expected {
+			t.Errorf("Initcode %x: expected hash %s, got %s", test.initCode, test.expected.Hex(), hash.Hex())
+		}
+
+		// The typed data signed by the signers hashes to the user operation hash
+		typedHash, _, err := apitypes.TypedDataAndHash(UserOpTypedDataV08(&packed, entrypointAddr, chainId, delegate))
+		if err != nil {
+			t.Fatalf("Failed to hash typed data: %v", err)
+		}
+		if common.BytesToHash(typedHash) != test.expected {
+			t.Errorf("Initcode %x: expected typed data hash %s, got %x", test.initCode, test.expected.Hex(), typedHash)
+		}
+	}
+}
+
+// typedDataSigner signs typed data only, as a remote signer without raw hash signing.
+type typedDataSigner struct {
+	Signer
+}
+
+func (s typedDataSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	return nil, ErrHashSigningUnsupported
+}
+
+// authorizationSigner signs authorizations only, as a remote signer without raw hash signing.
+type authorizationSigner struct {
+	typedDataSigner
+	key *ecdsa.PrivateKey
+}
+
+func (s authorizationSigner) SignAuthorization(ctx context.Context, chainId *big.Int, delegate common.Address, nonce uint64) ([]byte, error) {
+	hash, err := Eip7702AuthHash(chainId, delegate, nonce)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(hash.Bytes(), s.key)
+	if err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+func TestSignEip7702AuthSigners(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	delegate := common.HexToAddress("0xe6Cae83BdE06E4c305530e199D7217f42808555B")
+
+	// Without raw hash signing, the error is reported
+	_, err = SignEip7702Auth(context.Background(), typedDataSigner{NewPrivateKeySigner(key)}, big.NewInt(1), delegate, 7)
+	if !errors.Is(err, ErrHashSigningUnsupported) {
+		t.Errorf("Expected ErrHashSigningUnsupported, got %v", err)
+	}
+
+	// An AuthorizationSigner is used instead of SignHash
+	signer := authorizationSigner{typedDataSigner{NewPrivateKeySigner(key)}, key}
+	auth, err := SignEip7702Auth(context.Background(), signer, big.NewInt(1), delegate, 7)
+	if err != nil {
+		t.Fatalf("Failed to sign authorization: %v", err)
+	}
+	authority, err := auth.Authority()
+	if err != nil {
+		t.Fatalf("Failed to recover authority: %v", err)
+	}
+	if authority != signer.Address() {
+		t.Errorf("Expected authority %s, got %s", signer.Address().Hex(), authority.Hex())
+	}
+}
+
+func TestToBodyV08(t *testing.T) {
+	userOp := &UserOperation{
+		Sender:      common.HexToAddress("0x01"),
+		Nonce:       big.NewInt(7),
+		Eip7702Auth: &Eip7702Auth{ChainId: big.NewInt(1), Address: common.HexToAddress("0x02"), Nonce: 3},
+	}
+	// ToBody keeps its string map, the authorization is only sent to V0.8.0 bundlers
+	var body map[string]string = userOp.ToBody()
+	if _, ok := body["eip7702Auth"]; ok {
+		t.Errorf("Expected no authorization in the V0.7.0 body")
+	}
+	bodyV08 := userOp.ToBodyV08()
+	if bodyV08["sender"] != body["sender"] || bodyV08["nonce"] != "0x7" {
+		t.Errorf("Unexpected V0.8.0 body %v", bodyV08)
+	}
+	if bodyV08["eip7702Auth"] == nil {
+		t.Errorf("Expected the authorization in the V0.8.0 body")
+	}
+}
+
+func TestFillAndSignEip7702InitCode(t *testing.T) {
+	delegate := common.HexToAddress("0xe6Cae83BdE06E4c305530e199D7217f42808555B")
+	client, stop := newRpcStub(t, map[string]string{
+		"eth_getCode": `"0xef0100` + common.Bytes2Hex(delegate.Bytes()) + `"`,
+	}, nil)
+	defer stop()
+	client.version = EntryPointV08
+	client.config.Entrypoint = common.HexToAddress("0x4337084D9E255Ff0702461CF8895CE9E3b5Ff108")
+	client.config.Eip7702Delegate = delegate
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	// The user operation is signed as typed data, e.g. by a remote signer without raw hash signing
+	signer := typedDataSigner{NewPrivateKeySigner(key)}
+	initCode := append(Eip7702InitCodeMarker.Bytes(), 0xab, 0xcd)
+	userOp := &UserOperation{
+		Sender:               signer.Address(),
+		Nonce:                big.NewInt(0),
+		InitCode:             initCode,
+		CallData:             []byte{},
+		CallGasLimit:         big.NewInt(100000),
+		VerificationGasLimit: big.NewInt(200000),
+		PreVerificationGas:   big.NewInt(50000),
+		MaxFeePerGas:         big.NewInt(3000000000),
+		MaxPriorityFeePerGas: big.NewInt(1000000000),
+	}
+	signed, hash, err := client.FillAndSignWithOptions(context.Background(), userOp, signer, FillOptions{SkipPrefundCheck: true})
+	if err != nil {
+		t.Fatalf("Failed to fill and sign: %v", err)
+	}
+
+	// The caller's marker is kept and sent as the factory
+	if !bytes.Equal(signed.InitCode, initCode) {
+		t.Errorf("Expected init code %x, got %x", initCode, signed.InitCode)
+	}
+	if signed.Factory != Eip7702InitCodeMarker || !bytes.Equal(signed.FactoryData, []byte{0xab, 0xcd}) {
+		t.Errorf("Unexpected factory %s with data %x", signed.Factory.Hex(), signed.FactoryData)
+	}
+	if signed.Eip7702Auth != nil {
+		t.Errorf("Expected no authorization for an already delegated sender")
+	}
+
+	packed := PackUserOperation(signed)
+	expected, err := GetUserOpHashV08(&packed, client.config.Entrypoint, client.chainId, delegate)
+	if err != nil {
+		t.Fatalf("Failed to hash user operation: %v", err)
+	}
+	if hash != expected {
+		t.Errorf("Expected hash %s with the delegate, got %s", expected.Hex(), hash.Hex())
+	}
+	// The typed data hash is signed, without the EIP-191 prefix
+	recoverable := bytes.Clone(signed.Signature)
+	recoverable[crypto.RecoveryIDOffset] -= 27
+	pub, err := crypto.SigToPub(hash.Bytes(), recoverable)
+	if err != nil || crypto.PubkeyToAddress(*pub) != signer.Address() {
+		t.Errorf("Expected the signature to recover the signer, error %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package aasdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The JSON-RPC error codes of ERC-4337 bundlers.
+const (
+	CodeInvalidUserOpFields   = -32602
+	CodeRejectedByEntryPoint  = -32500
+	CodeRejectedByPaymaster   = -32501
+	CodeBannedOpcode          = -32502
+	CodeShortDeadline         = -32503
+	CodeThrottled             = -32504
+	CodeStakeTooLow           = -32505
+	CodeUnsupportedAggregator = -32506
+	CodeInvalidSignature      = -32507
+	CodeExecutionReverted     = -32521
+)
+
+var (
+	// ErrInvalidUserOpFields is returned when the user operation has invalid fields.
+	ErrInvalidUserOpFields = errors.New("invalid user operation fields")
+	// ErrRejectedByEntryPoint is returned when the account or factory validation fails.
+	ErrRejectedByEntryPoint = errors.New("rejected by entrypoint")
+	// ErrRejectedByPaymaster is returned when the paymaster validation fails.
+	ErrRejectedByPaymaster = errors.New("rejected by paymaster")
+	// ErrBannedOpcode is returned when the validation uses a banned opcode.
+	ErrBannedOpcode = errors.New("banned opcode")
+	// ErrShortDeadline is returned when the user operation or paymaster expires too soon, or isn't valid yet.
+	ErrShortDeadline = errors.New("short deadline")
+	// ErrThrottled is returned when the account, factory or paymaster is throttled or banned.
+	ErrThrottled = errors.New("throttled or banned")
+	// ErrStakeTooLow is returned when the paymaster, factory or aggregator stake is too low.
+	ErrStakeTooLow = errors.New("stake or unstake delay too low")
+	// ErrUnsupportedAggregator is returned when the bundler doesn't support the aggregator.
+	ErrUnsupportedAggregator = errors.New("unsupported aggregator")
+	// ErrInvalidSignature is returned when the account or aggregator signature check fails.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrExecutionReverted is returned when the execution reverts while estimating gas.
+	ErrExecutionReverted = errors.New("execution reverted")
+)
+
+var bundlerErrors = map[int]error{
+	CodeInvalidUserOpFields:   ErrInvalidUserOpFields,
+	CodeRejectedByEntryPoint:  ErrRejectedByEntryPoint,
+	CodeRejectedByPaymaster:   ErrRejectedByPaymaster,
+	CodeBannedOpcode:          ErrBannedOpcode,
+	CodeShortDeadline:         ErrShortDeadline,
+	CodeThrottled:             ErrThrottled,
+	CodeStakeTooLow:           ErrStakeTooLow,
+	CodeUnsupportedAggregator: ErrUnsupportedAggregator,
+	CodeInvalidSignature:      ErrInvalidSignature,
+	CodeExecutionReverted:     ErrExecutionReverted,
+}
+
+// BundlerError is a JSON-RPC error returned by the bundler.
+// It matches the Err* sentinel of its code with errors.Is.
+type BundlerError struct {
+	Code    int // 0 when the bundler returned no code
+	Message string
+	Data    json.RawMessage // the raw data field, if any
+}
+
+func (e *BundlerError) Error() string {
+	var parts []string
+	if e.Code != 0 {
+		parts = append(parts, fmt.Sprintf("code: %d", e.Code))
+	}
+	if e.Message != "" {
+		parts = append(parts, fmt.Sprintf("message: %s", e.Message))
+	}
+	if len(e.Data) > 0 && string(e.Data) != "null" {
+		parts = append(parts, fmt.Sprintf("data: %s", e.Data))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Unwrap returns the sentinel error of the code, or nil if the code is unknown.
+func (e *BundlerError) Unwrap() error {
+	return bundlerErrors[e.Code]
+}
+
+// DecodeData unmarshals the data field into v.
+func (e *BundlerError) DecodeData(v any) error {
+	if len(e.Data) == 0 {
+		return fmt.Errorf("no error data")
+	}
+	return json.Unmarshal(e.Data, v)
+}
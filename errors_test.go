@@ -0,0 +1,53 @@
+package aasdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestBundlerError(t *testing.T) {
+	raw := `{"jsonrpc":"2.0","id":1,"error":{"code":-32501,"message":"paymaster validation failed","data":{"paymaster":"0x0000000000000000000000000000000000000001"}}}`
+	var response jsonRpcResponse[any]
+	if err := json.Unmarshal([]byte(raw), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	err := fmt.Errorf("error from bundler: %w", response.Error.toError())
+
+	if !errors.Is(err, ErrRejectedByPaymaster) {
+		t.Errorf("Expected ErrRejectedByPaymaster, got %v", err)
+	}
+	if errors.Is(err, ErrRejectedByEntryPoint) {
+		t.Errorf("Expected not to match ErrRejectedByEntryPoint")
+	}
+	var bundlerErr *BundlerError
+	if !errors.As(err, &bundlerErr) {
+		t.Fatalf("Expected BundlerError, got %v", err)
+	}
+	if bundlerErr.Code != CodeRejectedByPaymaster {
+		t.Errorf("Expected code %d, got %d", CodeRejectedByPaymaster, bundlerErr.Code)
+	}
+	var data struct {
+		Paymaster string `json:"paymaster"`
+	}
+	if err := bundlerErr.DecodeData(&data); err != nil {
+		t.Fatalf("Failed to decode data: %v", err)
+	}
+	if data.Paymaster != "0x0000000000000000000000000000000000000001" {
+		t.Errorf("Unexpected paymaster %s", data.Paymaster)
+	}
+
+	// Unknown codes and string errors don't match any sentinel
+	if err := (&BundlerError{Code: -32000, Message: "internal"}); errors.Unwrap(err) != nil {
+		t.Errorf("Expected no sentinel for unknown code")
+	}
+	var stringResponse jsonRpcResponse[any]
+	raw = `{"jsonrpc":"2.0","id":1,"error":"rate limited"}`
+	if err := json.Unmarshal([]byte(raw), &stringResponse); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if got := stringResponse.Error.toError().Error(); got != "message: rate limited" {
+		t.Errorf("Unexpected error string %q", got)
+	}
+}
@@ -49,7 +49,7 @@ func main() {
 		Entrypoint:          common.HexToAddress("0xd308aE59cb31932E8D9305BAda32Fa782d3D5d42"),
 		AccountFactory:      common.HexToAddress("0xD421D8470b577f6A64992132D04906EfE51F1dE3"),
 		PaymasterAddress:    common.HexToAddress("0xe7db0C105Ac75A493B0413046417e48594360542"),
-		VerifyingSigner:     verifyingSigner,                                                        // optional, only used for verifying paymaster data
+		VerifyingSigner:     aasdk.NewPrivateKeySigner(verifyingSigner),                             // optional, only used for verifying paymaster data
 		ExecutorSigners:     aasdk.NewRoundRobinSignerProvider([]*ecdsa.PrivateKey{executorSigner}), // optional, only used for sending atomic transactions
 	}
 
@@ -64,15 +64,27 @@ func main() {
 	}
 	slog.Info("Supported entry points", "entrypoints", entrypoints)
 
-	signer, err := crypto.LoadECDSA(privateKeyFile)
-	if err != nil {
-		log.Fatalf("Failed to load signer: %v", err)
+	var signer aasdk.Signer
+	if keystoreFile := os.Getenv("OWNER_KEYSTORE"); keystoreFile != "" {
+		// Unlock the owner key from an encrypted keystore with the passphrase in OWNER_PASSPHRASE
+		keystoreSigner, err := aasdk.NewKeystoreSigner(keystoreFile, aasdk.PassphraseFromEnv("OWNER_PASSPHRASE"))
+		if err != nil {
+			log.Fatalf("Failed to load keystore: %v", err)
+		}
+		defer keystoreSigner.Close()
+		signer = keystoreSigner
+	} else {
+		ownerKey, err := crypto.LoadECDSA(privateKeyFile)
+		if err != nil {
+			log.Fatalf("Failed to load signer: %v", err)
+		}
+		signer = aasdk.NewPrivateKeySigner(ownerKey)
 	}
 
 	salt := big.NewInt(0)
 
 	// Example of creating a user operation
-	sender, err := client.GetAccount(context.Background(), crypto.PubkeyToAddress(signer.PublicKey), salt)
+	sender, err := client.GetAccount(context.Background(), signer.Address(), salt)
 	if err != nil {
 		log.Fatalf("Failed to get account: %v", err)
 	}
@@ -0,0 +1,353 @@
+package aasdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// DefaultFailoverPolicy provides the fields left zero in Config.Failover.
+	DefaultFailoverPolicy = FailoverPolicy{
+		MaxAttempts:      3,
+		RetryBackoff:     200 * time.Millisecond,
+		MaxRetryBackoff:  2 * time.Second,
+		FailureThreshold: 3,
+		Cooldown:         30 * time.Second,
+	}
+
+	// ErrSendOutcomeUnknown is returned when eth_sendUserOperation fails after the request may have reached the bundler.
+	// It isn't resent to avoid duplicates, the caller should look the user operation up by hash before resending.
+	ErrSendOutcomeUnknown = errors.New("user operation may have been sent")
+
+	// idempotentMethods are safe to retry, on the same or another bundler.
+	idempotentMethods = map[string]bool{
+		"eth_getUserOperationReceipt":  true,
+		"eth_estimateUserOperationGas": true,
+		"eth_getUserOperationByHash":   true,
+		"eth_supportedEntryPoints":     true,
+		"eth_chainId":                  true,
+		RundlerMaxPriorityFeeMethod:    true,
+		PimlicoUserOpGasPriceMethod:    true,
+	}
+)
+
+const (
+	// healthScoreWeight is the weight of the latest outcome in the health score.
+	healthScoreWeight = 0.2
+	// degradedHealthScore is the health score below which a bundler is only tried after the healthy ones.
+	degradedHealthScore = 0.5
+	sendMethod          = "eth_sendUserOperation"
+)
+
+// FailoverPolicy configures the retries and the circuit breaking across the bundler urls.
+// The zero fields default to the ones of DefaultFailoverPolicy.
+type FailoverPolicy struct {
+	// The maximum attempts of an idempotent call, across all bundlers.
+	MaxAttempts int
+	// The backoff before the first retry, doubled on each retry.
+	RetryBackoff time.Duration
+	// The maximum backoff between retries.
+	MaxRetryBackoff time.Duration
+	// The consecutive failures that open the circuit of a bundler, negative to disable circuit breaking.
+	FailureThreshold int
+	// How long a bundler with an open circuit is skipped.
+	Cooldown time.Duration
+}
+
+// BundlerHealth is the health of a bundler url.
+type BundlerHealth struct {
+	Url                 string
+	Score               float64 // the moving average of the success rate from 0 to 1, recovering without calls
+	ConsecutiveFailures int
+	CircuitOpen         bool
+}
+
+// endpoint is a bundler url with its health.
+type endpoint struct {
+	transport *transport
+
+	noBatch atomic.Bool // set once the bundler rejects a batch request
+
+	mu        sync.Mutex
+	score     float64
+	scoredAt  time.Time // the time of the last outcome
+	failures  int
+	openUntil time.Time
+}
+
+// currentScore returns the health score, recovering linearly to 1 over the cooldown since the last outcome,
+// so that a bundler skipped after a few failures is tried again. e.mu must be held.
+func (e *endpoint) currentScore(now time.Time, cooldown time.Duration) float64 {
+	if cooldown <= 0 || e.score >= 1 {
+		return e.score
+	}
+	recovered := float64(now.Sub(e.scoredAt)) / float64(cooldown)
+	if recovered >= 1 {
+		return 1
+	}
+	return e.score + (1-e.score)*recovered
+}
+
+func (e *endpoint) available(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return !now.Before(e.openUntil)
+}
+
+func (e *endpoint) healthScore(now time.Time, cooldown time.Duration) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.currentScore(now, cooldown)
+}
+
+func (e *endpoint) record(err error, policy FailoverPolicy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	outcome := 1.0
+	if err != nil {
+		outcome = 0
+	}
+	now := time.Now()
+	e.score = (1-healthScoreWeight)*e.currentScore(now, policy.Cooldown) + healthScoreWeight*outcome
+	e.scoredAt = now
+	if err == nil {
+		e.failures = 0
+		e.openUntil = time.Time{}
+		return
+	}
+	e.failures++
+	if policy.FailureThreshold > 0 && e.failures >= policy.FailureThreshold {
+		e.openUntil = now.Add(policy.Cooldown)
+	}
+}
+
+func (e *endpoint) health(policy FailoverPolicy) BundlerHealth {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	return BundlerHealth{
+		Url:                 e.transport.url,
+		Score:               e.currentScore(now, policy.Cooldown),
+		ConsecutiveFailures: e.failures,
+		CircuitOpen:         now.Before(e.openUntil),
+	}
+}
+
+// withDefaults returns the policy with the zero fields set from DefaultFailoverPolicy.
+func (p FailoverPolicy) withDefaults() FailoverPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultFailoverPolicy.MaxAttempts
+	}
+	if p.RetryBackoff <= 0 {
+		p.RetryBackoff = DefaultFailoverPolicy.RetryBackoff
+	}
+	if p.MaxRetryBackoff <= 0 {
+		p.MaxRetryBackoff = DefaultFailoverPolicy.MaxRetryBackoff
+	}
+	if p.FailureThreshold == 0 {
+		p.FailureThreshold = DefaultFailoverPolicy.FailureThreshold
+	}
+	if p.Cooldown <= 0 {
+		p.Cooldown = DefaultFailoverPolicy.Cooldown
+	}
+	return p
+}
+
+// bundlerPool dispatches JSON-RPC calls to a prioritized list of bundlers.
+type bundlerPool struct {
+	endpoints []*endpoint
+	policy    FailoverPolicy
+}
+
+// newBundlerPool creates a pool over the urls, in priority order.
+func newBundlerPool(urls []string, headers map[string]string, httpClient *http.Client, policy FailoverPolicy) *bundlerPool {
+	pool := &bundlerPool{policy: policy.withDefaults()}
+	for _, url := range urls {
+		pool.endpoints = append(pool.endpoints, &endpoint{
+			transport: newTransport(url, headers, httpClient),
+			score:     1,
+		})
+	}
+	return pool
+}
+
+// call makes a JSON-RPC call to the first available bundler, failing over to the next ones.
+// Idempotent methods are retried with backoff up to MaxAttempts. eth_sendUserOperation only fails over
+// when the bundler surely didn't receive it, otherwise ErrSendOutcomeUnknown is returned.
+// JSON-RPC errors are returned in the body and don't count as failures.
+func (p *bundlerPool) call(ctx context.Context, method string, params []any) ([]byte, error) {
+	if len(p.endpoints) == 0 {
+		return nil, fmt.Errorf("no bundler url configured")
+	}
+	candidates := p.candidates()
+	attempts := 1
+	if idempotentMethods[method] {
+		attempts = p.policy.MaxAttempts
+	}
+	// eth_sendUserOperation may still fail over to each candidate once if it's never received.
+	if method == sendMethod {
+		attempts = len(candidates)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 && method != sendMethod {
+			if err := sleepContext(ctx, p.backoff(attempt)); err != nil {
+				return nil, fmt.Errorf("%w, last error: %v", err, lastErr)
+			}
+		}
+		endpoint := candidates[attempt%len(candidates)]
+		body, err := endpoint.transport.call(ctx, method, params)
+		if err == nil {
+			endpoint.record(nil, p.policy)
+			return body, nil
+		}
+		if ctx.Err() != nil {
+			// The caller gave up, it says nothing about the bundler.
+			if method == sendMethod {
+				return nil, fmt.Errorf("%w: %w", ErrSendOutcomeUnknown, err)
+			}
+			return nil, err
+		}
+		endpoint.record(err, p.policy)
+		lastErr = err
+		if method == sendMethod && !notReceived(err) {
+			return nil, fmt.Errorf("%w: %w", ErrSendOutcomeUnknown, err)
+		}
+		if !retriable(err) {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+// batchCall makes the calls in a single batch request to the first available bundler.
+// It returns errBatchUnsupported if the bundler doesn't support batches, the caller should then fall back to call.
+func (p *bundlerPool) batchCall(ctx context.Context, method string, params [][]any) ([][]byte, error) {
+	if len(p.endpoints) == 0 {
+		return nil, fmt.Errorf("no bundler url configured")
+	}
+	endpoint := p.candidates()[0]
+	if endpoint.noBatch.Load() {
+		return nil, errBatchUnsupported
+	}
+	responses, err := endpoint.transport.batchCall(ctx, method, params)
+	if batchRejected(err) {
+		endpoint.noBatch.Store(true)
+		return nil, errBatchUnsupported
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			endpoint.record(err, p.policy)
+		}
+		return nil, err
+	}
+	endpoint.record(nil, p.policy)
+	return responses, nil
+}
+
+// candidates returns the endpoints to try: the available ones in priority order, the degraded ones
+// with a low health score last, or all of them when every circuit is open.
+func (p *bundlerPool) candidates() []*endpoint {
+	now := time.Now()
+	available := make([]*endpoint, 0, len(p.endpoints))
+	degraded := make(map[*endpoint]bool, len(p.endpoints))
+	for _, e := range p.endpoints {
+		if e.available(now) {
+			available = append(available, e)
+			degraded[e] = e.healthScore(now, p.policy.Cooldown) < degradedHealthScore
+		}
+	}
+	if len(available) == 0 {
+		return p.endpoints
+	}
+	sort.SliceStable(available, func(i, j int) bool {
+		return !degraded[available[i]] && degraded[available[j]]
+	})
+	return available
+}
+
+func (p *bundlerPool) backoff(attempt int) time.Duration {
+	backoff := p.policy.RetryBackoff << (attempt - 1)
+	if p.policy.MaxRetryBackoff > 0 && (backoff > p.policy.MaxRetryBackoff || backoff <= 0) {
+		backoff = p.policy.MaxRetryBackoff
+	}
+	return backoff
+}
+
+func (p *bundlerPool) health() []BundlerHealth {
+	result := make([]BundlerHealth, len(p.endpoints))
+	for i, e := range p.endpoints {
+		result[i] = e.health(p.policy)
+	}
+	return result
+}
+
+// batchRejected checks if the bundler doesn't support batch requests: it doesn't answer with an array,
+// or rejects the request as malformed, unknown, too large or not implemented.
+// Transient statuses, such as 401, 403, 408, 429 or 502, don't disable batches for good.
+func batchRejected(err error) bool {
+	if errors.Is(err, errBatchUnsupported) {
+		return true
+	}
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	switch httpErr.StatusCode {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusRequestEntityTooLarge, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// notReceived checks if the request surely didn't reach the bundler:
+// the connection couldn't be established, or the bundler refused it with 429 or 503.
+func notReceived(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode == http.StatusServiceUnavailable
+	}
+	return false
+}
+
+// retriable checks if the call may succeed when retried: transport errors, 429 and 5xx responses.
+// Other errors, e.g. encoding the request, fail the same way on every bundler.
+// The caller must check its context first, a cancelled request is a transport error too.
+func retriable(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// sleepContext waits for the duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// BundlerHealth returns the health of the bundler urls, in priority order.
+func (c *Client) BundlerHealth() []BundlerHealth {
+	return c.bundler.health()
+}
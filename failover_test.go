@@ -0,0 +1,257 @@
+package aasdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBundlerPoolFailover(t *testing.T) {
+	var primaryStatus atomic.Int32
+	var primaryCalls, fallbackCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		w.WriteHeader(int(primaryStatus.Load()))
+	}))
+	defer primary.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackCalls.Add(1)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`))
+	}))
+	defer fallback.Close()
+
+	policy := FailoverPolicy{
+		MaxAttempts:      3,
+		RetryBackoff:     time.Millisecond,
+		MaxRetryBackoff:  time.Millisecond,
+		FailureThreshold: 2,
+		Cooldown:         time.Minute,
+	}
+	pool := newBundlerPool([]string{primary.URL, fallback.URL}, nil, nil, policy)
+	ctx := context.Background()
+
+	// Idempotent calls fail over to the next bundler
+	primaryStatus.Store(http.StatusBadGateway)
+	if _, err := pool.call(ctx, "eth_getUserOperationReceipt", []any{"0x01"}); err != nil {
+		t.Fatalf("Expected failover to succeed, got %v", err)
+	}
+	if primaryCalls.Load() != 1 || fallbackCalls.Load() != 1 {
+		t.Errorf("Expected one call per bundler, got %d and %d", primaryCalls.Load(), fallbackCalls.Load())
+	}
+
+	// A send that may have reached the bundler isn't resent
+	primaryStatus.Store(http.StatusInternalServerError)
+	if _, err := pool.call(ctx, "eth_sendUserOperation", nil); !errors.Is(err, ErrSendOutcomeUnknown) {
+		t.Errorf("Expected ErrSendOutcomeUnknown, got %v", err)
+	}
+	if fallbackCalls.Load() != 1 {
+		t.Errorf("Expected the send not to be duplicated, got %d fallback calls", fallbackCalls.Load())
+	}
+
+	// The primary circuit is open after two consecutive failures
+	health := pool.health()
+	if !health[0].CircuitOpen || health[0].ConsecutiveFailures != 2 {
+		t.Errorf("Expected the primary circuit to be open, got %+v", health[0])
+	}
+	if _, err := pool.call(ctx, "eth_sendUserOperation", nil); err != nil {
+		t.Fatalf("Expected send to go to the fallback, got %v", err)
+	}
+	if primaryCalls.Load() != 2 || fallbackCalls.Load() != 2 {
+		t.Errorf("Expected the primary to be skipped, got %d and %d calls", primaryCalls.Load(), fallbackCalls.Load())
+	}
+}
+
+func TestBundlerPoolSendNotReceived(t *testing.T) {
+	var calls atomic.Int32
+	busy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer busy.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x01"}`))
+	}))
+	defer fallback.Close()
+
+	pool := newBundlerPool([]string{busy.URL, fallback.URL}, nil, nil, FailoverPolicy{})
+	if _, err := pool.call(context.Background(), "eth_sendUserOperation", nil); err != nil {
+		t.Fatalf("Expected send to fail over on 503, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected one call to the fallback, got %d", calls.Load())
+	}
+}
+
+func TestBundlerPoolDegraded(t *testing.T) {
+	var primaryCalls, fallbackCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`))
+	}))
+	defer primary.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackCalls.Add(1)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`))
+	}))
+	defer fallback.Close()
+
+	// Without circuit breaking, only the health score demotes the primary
+	policy := FailoverPolicy{MaxAttempts: 1, FailureThreshold: -1, Cooldown: time.Minute}
+	pool := newBundlerPool([]string{primary.URL, fallback.URL}, nil, nil, policy)
+	primaryEndpoint := pool.endpoints[0]
+	for i := 0; i < 3; i++ {
+		primaryEndpoint.record(errors.New("failed"), policy)
+	}
+	if candidates := pool.candidates(); candidates[0] != primaryEndpoint {
+		t.Errorf("Expected the primary to stay first with score %v", pool.health()[0].Score)
+	}
+	primaryEndpoint.record(errors.New("failed"), policy)
+	if candidates := pool.candidates(); candidates[0] != pool.endpoints[1] || candidates[1] != primaryEndpoint {
+		t.Errorf("Expected the degraded primary to be tried last with score %v", pool.health()[0].Score)
+	}
+	if _, err := pool.call(context.Background(), "eth_getUserOperationReceipt", []any{"0x01"}); err != nil {
+		t.Fatalf("Failed to call: %v", err)
+	}
+	if primaryCalls.Load() != 0 || fallbackCalls.Load() != 1 {
+		t.Errorf("Expected the call to go to the fallback, got %d and %d calls", primaryCalls.Load(), fallbackCalls.Load())
+	}
+
+	// The score recovers over the cooldown
+	primaryEndpoint.mu.Lock()
+	primaryEndpoint.scoredAt = time.Now().Add(-policy.Cooldown / 2)
+	primaryEndpoint.mu.Unlock()
+	if score := pool.health()[0].Score; score < 0.7 || score > 0.71 {
+		t.Errorf("Expected the score to recover halfway, got %v", score)
+	}
+	if candidates := pool.candidates(); candidates[0] != primaryEndpoint {
+		t.Errorf("Expected the recovered primary to be first")
+	}
+}
+
+func TestBundlerPoolSendOpenCircuit(t *testing.T) {
+	var openCalls, busyCalls atomic.Int32
+	open := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		openCalls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer open.Close()
+	busy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		busyCalls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer busy.Close()
+
+	policy := FailoverPolicy{MaxAttempts: 1, FailureThreshold: 1, Cooldown: time.Minute}
+	pool := newBundlerPool([]string{open.URL, busy.URL}, nil, nil, policy)
+	pool.endpoints[0].record(errors.New("failed"), policy)
+
+	// The send goes to each candidate once, the bundler left isn't sent the user operation twice
+	if _, err := pool.call(context.Background(), "eth_sendUserOperation", nil); err == nil {
+		t.Fatalf("Expected the send to fail")
+	}
+	if openCalls.Load() != 0 || busyCalls.Load() != 1 {
+		t.Errorf("Expected one call to the available bundler, got %d and %d", openCalls.Load(), busyCalls.Load())
+	}
+}
+
+func TestBundlerPoolCancelled(t *testing.T) {
+	var calls atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		cancel()
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	policy := FailoverPolicy{MaxAttempts: 3, RetryBackoff: time.Millisecond}
+	pool := newBundlerPool([]string{server.URL}, nil, nil, policy)
+	if _, err := pool.call(ctx, "eth_getUserOperationReceipt", []any{"0x01"}); err == nil {
+		t.Fatalf("Expected the call to fail")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected no retry after cancellation, got %d calls", calls.Load())
+	}
+	if health := pool.health()[0]; health.ConsecutiveFailures != 0 {
+		t.Errorf("Expected the cancellation not to count against the bundler, got %+v", health)
+	}
+}
+
+func TestFailoverPolicyDefaults(t *testing.T) {
+	// The fields left zero keep their defaults, circuit breaking and backoff included
+	policy := newBundlerPool(nil, nil, nil, FailoverPolicy{MaxAttempts: 5}).policy
+	expected := DefaultFailoverPolicy
+	expected.MaxAttempts = 5
+	if policy != expected {
+		t.Errorf("Expected policy %+v, got %+v", expected, policy)
+	}
+	if policy := newBundlerPool(nil, nil, nil, FailoverPolicy{}).policy; policy != DefaultFailoverPolicy {
+		t.Errorf("Expected the default policy, got %+v", policy)
+	}
+	if policy := newBundlerPool(nil, nil, nil, FailoverPolicy{FailureThreshold: -1}).policy; policy.FailureThreshold != -1 {
+		t.Errorf("Expected circuit breaking to stay disabled, got %+v", policy)
+	}
+}
+
+func TestBundlerPoolBatchRejected(t *testing.T) {
+	var status atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+	pool := newBundlerPool([]string{server.URL}, nil, nil, FailoverPolicy{})
+	ctx := context.Background()
+
+	// A transient auth failure is a failed call, batches are still tried afterwards
+	for _, code := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests} {
+		status.Store(int32(code))
+		if _, err := pool.batchCall(ctx, "eth_chainId", [][]any{{}}); err == nil || errors.Is(err, errBatchUnsupported) {
+			t.Errorf("Expected a failed batch for status %d, got %v", code, err)
+		}
+		if pool.endpoints[0].noBatch.Load() {
+			t.Fatalf("Expected batches to stay enabled after status %d", code)
+		}
+	}
+
+	// A rejected batch disables batches
+	status.Store(http.StatusMethodNotAllowed)
+	if _, err := pool.batchCall(ctx, "eth_chainId", [][]any{{}}); !errors.Is(err, errBatchUnsupported) {
+		t.Errorf("Expected errBatchUnsupported, got %v", err)
+	}
+	if !pool.endpoints[0].noBatch.Load() {
+		t.Errorf("Expected batches to be disabled")
+	}
+}
+
+func TestRetriable(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{&HTTPError{StatusCode: http.StatusBadGateway}, true},
+		{&HTTPError{StatusCode: http.StatusTooManyRequests}, true},
+		{&HTTPError{StatusCode: http.StatusBadRequest}, false},
+		{fmt.Errorf("error reading response body: %w", io.ErrUnexpectedEOF), true},
+		{fmt.Errorf("error marshalling payload: %v", errors.New("unsupported type")), false},
+		{&BundlerError{Code: -32602, Message: "invalid params"}, false},
+	}
+	for _, test := range tests {
+		if got := retriable(test.err); got != test.expected {
+			t.Errorf("retriable(%v): expected %v, got %v", test.err, test.expected, got)
+		}
+	}
+
+	// Connection errors are retried
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+	_, err := newTransport(server.URL, nil, nil).call(context.Background(), "eth_chainId", nil)
+	if err == nil || !retriable(err) {
+		t.Errorf("Expected the connection error to be retriable, got %v", err)
+	}
+}
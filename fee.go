@@ -0,0 +1,193 @@
+package aasdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// FeeSpeed is the fee preset used to price user operations.
+type FeeSpeed string
+
+const (
+	FeeSpeedSlow     FeeSpeed = "slow"
+	FeeSpeedStandard FeeSpeed = "standard"
+	FeeSpeedFast     FeeSpeed = "fast"
+)
+
+const (
+	// RundlerMaxPriorityFeeMethod returns the minimum priority fee accepted by Rundler.
+	RundlerMaxPriorityFeeMethod = "rundler_maxPriorityFeePerGas"
+	// PimlicoUserOpGasPriceMethod returns the slow, standard and fast fees accepted by Pimlico-compatible bundlers.
+	PimlicoUserOpGasPriceMethod = "pimlico_getUserOperationGasPrice"
+
+	// The number of blocks sampled by eth_feeHistory.
+	feeHistoryBlocks = 10
+)
+
+// The eth_feeHistory reward percentile for each preset.
+var feeRewardPercentiles = map[FeeSpeed]float64{
+	FeeSpeedSlow:     10,
+	FeeSpeedStandard: 50,
+	FeeSpeedFast:     90,
+}
+
+// The headroom on the latest base fee for each preset, in percent.
+var feeBaseFeeMultipliers = map[FeeSpeed]int64{
+	FeeSpeedSlow:     110,
+	FeeSpeedStandard: 125,
+	FeeSpeedFast:     200,
+}
+
+// Fees holds the EIP-1559 fees of a user operation.
+type Fees struct {
+	MaxFeePerGas         *big.Int `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *big.Int `json:"maxPriorityFeePerGas"`
+}
+
+// FeeOracle suggests the fees of user operations.
+type FeeOracle interface {
+	// SuggestFees returns the fees for the given preset.
+	SuggestFees(ctx context.Context, speed FeeSpeed) (*Fees, error)
+}
+
+var _ FeeOracle = &Client{}
+
+// SuggestFees returns the fees for the given preset.
+// The fees come from the bundler when Config.FeeBundlerMethod is PimlicoUserOpGasPriceMethod,
+// otherwise they are derived from the latest base fee and the eth_feeHistory priority fees.
+func (c *Client) SuggestFees(ctx context.Context, speed FeeSpeed) (*Fees, error) {
+	if speed == "" {
+		speed = FeeSpeedStandard
+	}
+	percentile, ok := feeRewardPercentiles[speed]
+	if !ok {
+		return nil, fmt.Errorf("unknown fee speed: %s", speed)
+	}
+	if c.config.FeeBundlerMethod == PimlicoUserOpGasPriceMethod {
+		return c.bundlerGasPrice(ctx, speed)
+	}
+
+	head, err := c.eth.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting latest header: %v", err)
+	}
+	if head.BaseFee == nil {
+		// Legacy network, the gas price is paid in full
+		gasPrice, err := c.eth.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting gas price: %v", err)
+		}
+		return &Fees{MaxFeePerGas: gasPrice, MaxPriorityFeePerGas: new(big.Int).Set(gasPrice)}, nil
+	}
+
+	priorityFee, err := c.historicalPriorityFee(ctx, percentile)
+	if err != nil {
+		return nil, err
+	}
+	if c.config.FeeBundlerMethod == RundlerMaxPriorityFeeMethod {
+		minPriorityFee, err := c.bundlerMaxPriorityFee(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if priorityFee.Cmp(minPriorityFee) < 0 {
+			priorityFee = minPriorityFee
+		}
+	}
+
+	maxFee := new(big.Int).Mul(head.BaseFee, big.NewInt(feeBaseFeeMultipliers[speed]))
+	maxFee.Div(maxFee, big.NewInt(100))
+	maxFee.Add(maxFee, priorityFee)
+	return &Fees{MaxFeePerGas: maxFee, MaxPriorityFeePerGas: priorityFee}, nil
+}
+
+// historicalPriorityFee returns the median of the priority fees paid at the given percentile over the recent blocks.
+// It falls back to eth_maxPriorityFeePerGas when the history has no rewards.
+func (c *Client) historicalPriorityFee(ctx context.Context, percentile float64) (*big.Int, error) {
+	history, err := c.eth.FeeHistory(ctx, feeHistoryBlocks, nil, []float64{percentile})
+	if err != nil {
+		return nil, fmt.Errorf("error getting fee history: %v", err)
+	}
+	var rewards []*big.Int
+	for _, reward := range history.Reward {
+		if len(reward) > 0 && reward[0] != nil && reward[0].Sign() > 0 {
+			rewards = append(rewards, reward[0])
+		}
+	}
+	if len(rewards) == 0 {
+		tip, err := c.eth.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting gas tip cap: %v", err)
+		}
+		return tip, nil
+	}
+	sort.Slice(rewards, func(i, j int) bool {
+		return rewards[i].Cmp(rewards[j]) < 0
+	})
+	return new(big.Int).Set(rewards[len(rewards)/2]), nil
+}
+
+// bundlerMaxPriorityFee returns the minimum priority fee accepted by the bundler.
+func (c *Client) bundlerMaxPriorityFee(ctx context.Context) (*big.Int, error) {
+	bytes, err := c.call(ctx, RundlerMaxPriorityFeeMethod, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error calling %s: %w", RundlerMaxPriorityFeeMethod, err)
+	}
+	var response jsonRpcResponse[*hexutil.Big]
+	if err = json.Unmarshal(bytes, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshalling max priority fee: %v", err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("error from bundler: %w", response.Error.toError())
+	}
+	if response.Result == nil {
+		return nil, fmt.Errorf("no max priority fee response")
+	}
+	return (*big.Int)(response.Result), nil
+}
+
+// bundlerGasPrice returns the fees suggested by the bundler for the given preset.
+func (c *Client) bundlerGasPrice(ctx context.Context, speed FeeSpeed) (*Fees, error) {
+	bytes, err := c.call(ctx, PimlicoUserOpGasPriceMethod, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error calling %s: %w", PimlicoUserOpGasPriceMethod, err)
+	}
+	type gasPrice struct {
+		MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas"`
+		MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas"`
+	}
+	var response jsonRpcResponse[map[FeeSpeed]*gasPrice]
+	if err = json.Unmarshal(bytes, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshalling user op gas price: %v", err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("error from bundler: %w", response.Error.toError())
+	}
+	price := response.Result[speed]
+	if price == nil || price.MaxFeePerGas == nil || price.MaxPriorityFeePerGas == nil {
+		return nil, fmt.Errorf("no %s gas price response", speed)
+	}
+	return &Fees{
+		MaxFeePerGas:         (*big.Int)(price.MaxFeePerGas),
+		MaxPriorityFeePerGas: (*big.Int)(price.MaxPriorityFeePerGas),
+	}, nil
+}
+
+// fillFees fills the fees of the user operation with the configured fee oracle.
+func (c *Client) fillFees(ctx context.Context, userOp *UserOperation) error {
+	var oracle FeeOracle = c
+	if c.config.FeeOracle != nil {
+		oracle = c.config.FeeOracle
+	}
+	fees, err := oracle.SuggestFees(ctx, c.config.FeeSpeed)
+	if err != nil {
+		return err
+	}
+	userOp.MaxFeePerGas = fees.MaxFeePerGas
+	userOp.MaxPriorityFeePerGas = fees.MaxPriorityFeePerGas
+	return nil
+}
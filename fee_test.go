@@ -0,0 +1,169 @@
+package aasdk
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// newRpcStub starts a node and bundler answering each method with its result, or null.
+// The methods are recorded in the calls, if set.
+func newRpcStub(t *testing.T, results map[string]string, calls map[string]int) (*Client, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Id     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if calls != nil {
+			calls[request.Method]++
+		}
+		result, ok := results[request.Method]
+		if !ok {
+			result = "null"
+		}
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": request.Id, "result": json.RawMessage(result)})
+	}))
+	eth, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create eth client: %v", err)
+	}
+	client := &Client{
+		chainId: big.NewInt(1),
+		config:  &Config{},
+		eth:     eth,
+		bundler: newBundlerPool([]string{server.URL}, nil, nil, FailoverPolicy{MaxAttempts: 1}),
+	}
+	return client, server.Close
+}
+
+// headerJson encodes a complete block header at height 16 with the base fee, if any.
+func headerJson(t *testing.T, baseFee *big.Int) string {
+	header, err := json.Marshal(&types.Header{
+		Number:     big.NewInt(16),
+		Difficulty: new(big.Int),
+		BaseFee:    baseFee,
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode header: %v", err)
+	}
+	return string(header)
+}
+
+func TestSuggestFees(t *testing.T) {
+	client, stop := newRpcStub(t, map[string]string{
+		"eth_getBlockByNumber": headerJson(t, big.NewInt(100)),
+		"eth_feeHistory":       `{"oldestBlock":"0x6","reward":[["0x1"],["0x3"],["0x0"],["0x2"]],"gasUsedRatio":[0.5,0.5,0.5,0.5]}`,
+		// The odd-length quantities are decoded
+		RundlerMaxPriorityFeeMethod: `"0x5"`,
+	}, nil)
+	defer stop()
+
+	// The median of the non-zero rewards on top of the base fee with headroom
+	fees, err := client.SuggestFees(context.Background(), FeeSpeedStandard)
+	if err != nil {
+		t.Fatalf("Failed to suggest fees: %v", err)
+	}
+	if fees.MaxPriorityFeePerGas.Int64() != 2 || fees.MaxFeePerGas.Int64() != 100*125/100+2 {
+		t.Errorf("Unexpected fees %+v", fees)
+	}
+
+	// The bundler minimum priority fee is a floor
+	client.config.FeeBundlerMethod = RundlerMaxPriorityFeeMethod
+	fees, err = client.SuggestFees(context.Background(), FeeSpeedFast)
+	if err != nil {
+		t.Fatalf("Failed to suggest fees: %v", err)
+	}
+	if fees.MaxPriorityFeePerGas.Int64() != 5 || fees.MaxFeePerGas.Int64() != 100*200/100+5 {
+		t.Errorf("Unexpected fees with the bundler minimum %+v", fees)
+	}
+
+	if _, err := client.SuggestFees(context.Background(), "turbo"); err == nil {
+		t.Error("Expected error for an unknown fee speed")
+	}
+}
+
+func TestSuggestFeesLegacy(t *testing.T) {
+	client, stop := newRpcStub(t, map[string]string{
+		"eth_getBlockByNumber": headerJson(t, nil),
+		"eth_gasPrice":         `"0x3b9aca00"`,
+	}, nil)
+	defer stop()
+
+	fees, err := client.SuggestFees(context.Background(), FeeSpeedStandard)
+	if err != nil {
+		t.Fatalf("Failed to suggest fees: %v", err)
+	}
+	if fees.MaxFeePerGas.Int64() != 1e9 || fees.MaxPriorityFeePerGas.Int64() != 1e9 {
+		t.Errorf("Expected the gas price for both fees, got %+v", fees)
+	}
+}
+
+func TestHistoricalPriorityFee(t *testing.T) {
+	calls := make(map[string]int)
+	client, stop := newRpcStub(t, map[string]string{
+		"eth_feeHistory":           `{"oldestBlock":"0x6","reward":[["0x0"],[]],"gasUsedRatio":[0,0]}`,
+		"eth_maxPriorityFeePerGas": `"0x7"`,
+	}, calls)
+	defer stop()
+
+	// The node suggestion is the fallback for an empty history
+	fee, err := client.historicalPriorityFee(context.Background(), 50)
+	if err != nil {
+		t.Fatalf("Failed to get priority fee: %v", err)
+	}
+	if fee.Int64() != 7 || calls["eth_maxPriorityFeePerGas"] != 1 {
+		t.Errorf("Expected the eth_maxPriorityFeePerGas fallback of 7, got %s", fee)
+	}
+}
+
+func TestBundlerMaxPriorityFee(t *testing.T) {
+	client, stop := newRpcStub(t, map[string]string{
+		RundlerMaxPriorityFeeMethod: `"0xabc"`,
+	}, nil)
+	defer stop()
+
+	fee, err := client.bundlerMaxPriorityFee(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get max priority fee: %v", err)
+	}
+	if fee.Int64() != 0xabc {
+		t.Errorf("Expected 0xabc, got %s", fee)
+	}
+}
+
+func TestBundlerGasPrice(t *testing.T) {
+	client, stop := newRpcStub(t, map[string]string{
+		PimlicoUserOpGasPriceMethod: `{
+			"slow":{"maxFeePerGas":"0x10","maxPriorityFeePerGas":"0x1"},
+			"standard":{"maxFeePerGas":"0x20","maxPriorityFeePerGas":"0x2"},
+			"fast":{"maxFeePerGas":"0x30","maxPriorityFeePerGas":"0x3"}
+		}`,
+	}, nil)
+	defer stop()
+
+	client.config.FeeBundlerMethod = PimlicoUserOpGasPriceMethod
+	fees, err := client.SuggestFees(context.Background(), FeeSpeedFast)
+	if err != nil {
+		t.Fatalf("Failed to suggest fees: %v", err)
+	}
+	if fees.MaxFeePerGas.Int64() != 0x30 || fees.MaxPriorityFeePerGas.Int64() != 0x3 {
+		t.Errorf("Unexpected fast fees %+v", fees)
+	}
+
+	fees, err = client.bundlerGasPrice(context.Background(), FeeSpeedSlow)
+	if err != nil {
+		t.Fatalf("Failed to get gas price: %v", err)
+	}
+	if fees.MaxFeePerGas.Int64() != 0x10 || fees.MaxPriorityFeePerGas.Int64() != 0x1 {
+		t.Errorf("Unexpected slow fees %+v", fees)
+	}
+}
@@ -0,0 +1,73 @@
+package aasdk
+
+import (
+	"context"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// DummySignature is a well-formed ECDSA signature used in place of the real ones while estimating gas.
+	// Unlike EmptySignature, it doesn't revert in ECDSA.recover, so validation runs through.
+	DummySignature = common.FromHex("0xfffffffffffffffffffffffffffffff0000000000000000000000000000000007aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa1c")
+
+	// DefaultGasMultipliers leaves a safety margin on the bundler gas estimates.
+	DefaultGasMultipliers = GasMultipliers{
+		PreVerificationGas:            1.1,
+		VerificationGasLimit:          1.2,
+		CallGasLimit:                  1.2,
+		PaymasterVerificationGasLimit: 1.2,
+		PaymasterPostOpGasLimit:       1.2,
+	}
+)
+
+// gasMultiplierPrecision is the number of decimals kept when applying a multiplier.
+const gasMultiplierPrecision = 10000
+
+// GasMultipliers scales the gas estimates returned by the bundler per field.
+// A zero multiplier keeps the estimate as is.
+type GasMultipliers struct {
+	PreVerificationGas            float64
+	VerificationGasLimit          float64
+	CallGasLimit                  float64
+	PaymasterVerificationGasLimit float64
+	PaymasterPostOpGasLimit       float64
+}
+
+// estimateGas fills the gas limits of the user operation with the bundler estimates.
+// The estimation runs with a dummy user signature and the paymaster stub data,
+// the real signatures depend on the gas limits.
+func (c *Client) estimateGas(ctx context.Context, userOp *UserOperation) error {
+	estimateOp := *userOp
+	estimateOp.Signature = DummySignature
+
+	estimates, err := c.EstimateUserOpGas(ctx, &estimateOp)
+	if err != nil {
+		return err
+	}
+
+	multipliers := c.config.GasMultipliers
+	userOp.PreVerificationGas = applyGasMultiplier(estimates.PreVerificationGas, multipliers.PreVerificationGas, userOp.PreVerificationGas)
+	userOp.VerificationGasLimit = applyGasMultiplier(estimates.VerificationGasLimit, multipliers.VerificationGasLimit, userOp.VerificationGasLimit)
+	userOp.CallGasLimit = applyGasMultiplier(estimates.CallGasLimit, multipliers.CallGasLimit, userOp.CallGasLimit)
+	if userOp.Paymaster != (common.Address{}) {
+		userOp.PaymasterVerificationGasLimit = applyGasMultiplier(estimates.PaymasterVerificationGasLimit, multipliers.PaymasterVerificationGasLimit, userOp.PaymasterVerificationGasLimit)
+		userOp.PaymasterPostOpGasLimit = applyGasMultiplier(estimates.PaymasterPostOpGasLimit, multipliers.PaymasterPostOpGasLimit, userOp.PaymasterPostOpGasLimit)
+	}
+	return nil
+}
+
+// applyGasMultiplier returns the estimate scaled by the multiplier.
+// It returns the fallback value if there is no estimate.
+func applyGasMultiplier(estimate *big.Int, multiplier float64, fallback *big.Int) *big.Int {
+	if estimate == nil {
+		return fallback
+	}
+	if multiplier <= 0 {
+		return new(big.Int).Set(estimate)
+	}
+	scaled := new(big.Int).Mul(estimate, big.NewInt(int64(math.Round(multiplier*gasMultiplierPrecision))))
+	return scaled.Div(scaled, big.NewInt(gasMultiplierPrecision))
+}
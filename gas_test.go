@@ -0,0 +1,129 @@
+package aasdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestApplyGasMultiplier(t *testing.T) {
+	tests := []struct {
+		estimate   *big.Int
+		multiplier float64
+		fallback   *big.Int
+		expected   *big.Int
+	}{
+		{big.NewInt(1000), 1.2, nil, big.NewInt(1200)},
+		{big.NewInt(1000), 1.0001, nil, big.NewInt(1000)},
+		{big.NewInt(100000), 1.0001, nil, big.NewInt(100010)},
+		// 1.005 is slightly below in floating point
+		{big.NewInt(100000), 1.005, nil, big.NewInt(100500)},
+		{big.NewInt(1000), 0, nil, big.NewInt(1000)},
+		{big.NewInt(1000), -1, nil, big.NewInt(1000)},
+		{nil, 1.2, big.NewInt(7), big.NewInt(7)},
+		{nil, 1.2, nil, nil},
+	}
+	for _, test := range tests {
+		result := applyGasMultiplier(test.estimate, test.multiplier, test.fallback)
+		if (result == nil) != (test.expected == nil) || (result != nil && result.Cmp(test.expected) != 0) {
+			t.Errorf("applyGasMultiplier(%s, %v, %s): expected %s, got %s", test.estimate, test.multiplier, test.fallback, test.expected, result)
+		}
+	}
+
+	// The estimate isn't modified
+	estimate := big.NewInt(1000)
+	applyGasMultiplier(estimate, 1.5, nil)
+	if estimate.Int64() != 1000 {
+		t.Errorf("Expected the estimate to be unchanged, got %s", estimate)
+	}
+}
+
+func TestEstimateGas(t *testing.T) {
+	var estimated map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Id     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if request.Method != "eth_estimateUserOperationGas" {
+			t.Errorf("Unexpected method %s", request.Method)
+		}
+		if err := json.Unmarshal(request.Params[0], &estimated); err != nil {
+			t.Errorf("Failed to decode user operation: %v", err)
+		}
+		// The odd-length quantities are decoded
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": request.Id, "result": map[string]string{
+			"preVerificationGas":            "0x3e8",
+			"verificationGasLimit":          "0x2710",
+			"callGasLimit":                  "0x1",
+			"paymasterVerificationGasLimit": "0x64",
+			"paymasterPostOpGasLimit":       "0x5",
+		}})
+	}))
+	defer server.Close()
+
+	client := &Client{
+		chainId: big.NewInt(1),
+		config:  &Config{GasMultipliers: DefaultGasMultipliers},
+		bundler: newBundlerPool([]string{server.URL}, nil, nil, FailoverPolicy{MaxAttempts: 1}),
+	}
+	signature := []byte{0x01, 0x02}
+	userOp := &UserOperation{
+		Sender:        common.HexToAddress("0x01"),
+		Nonce:         big.NewInt(0),
+		CallData:      []byte{},
+		Paymaster:     common.HexToAddress("0x02"),
+		PaymasterData: []byte{0x03},
+		Signature:     signature,
+	}
+	if err := client.estimateGas(context.Background(), userOp); err != nil {
+		t.Fatalf("Failed to estimate gas: %v", err)
+	}
+
+	// The estimation runs with the dummy signature and keeps the caller's
+	if estimated["signature"] != "0x"+common.Bytes2Hex(DummySignature) {
+		t.Errorf("Expected the dummy signature in the estimation, got %s", estimated["signature"])
+	}
+	if !bytes.Equal(userOp.Signature, signature) {
+		t.Errorf("Expected the user operation signature to be unchanged, got %x", userOp.Signature)
+	}
+
+	expected := map[string]*big.Int{
+		"preVerificationGas":            big.NewInt(1100),
+		"verificationGasLimit":          big.NewInt(12000),
+		"callGasLimit":                  big.NewInt(1),
+		"paymasterVerificationGasLimit": big.NewInt(120),
+		"paymasterPostOpGasLimit":       big.NewInt(6),
+	}
+	actual := map[string]*big.Int{
+		"preVerificationGas":            userOp.PreVerificationGas,
+		"verificationGasLimit":          userOp.VerificationGasLimit,
+		"callGasLimit":                  userOp.CallGasLimit,
+		"paymasterVerificationGasLimit": userOp.PaymasterVerificationGasLimit,
+		"paymasterPostOpGasLimit":       userOp.PaymasterPostOpGasLimit,
+	}
+	for field, value := range expected {
+		if actual[field] == nil || actual[field].Cmp(value) != 0 {
+			t.Errorf("Expected %s %s, got %s", field, value, actual[field])
+		}
+	}
+
+	// The paymaster estimates are ignored without a paymaster
+	userOp = &UserOperation{Sender: common.HexToAddress("0x01"), Nonce: big.NewInt(0)}
+	if err := client.estimateGas(context.Background(), userOp); err != nil {
+		t.Fatalf("Failed to estimate gas: %v", err)
+	}
+	if userOp.PaymasterVerificationGasLimit != nil || userOp.PaymasterPostOpGasLimit != nil {
+		t.Errorf("Expected no paymaster gas limits, got %s and %s", userOp.PaymasterVerificationGasLimit, userOp.PaymasterPostOpGasLimit)
+	}
+}
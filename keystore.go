@@ -0,0 +1,278 @@
+package aasdk
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// PassphraseFunc returns the passphrase unlocking the keystore of the given address.
+type PassphraseFunc func(address common.Address) (string, error)
+
+// PassphraseFromEnv reads the passphrase from the given environment variable.
+func PassphraseFromEnv(name string) PassphraseFunc {
+	return func(address common.Address) (string, error) {
+		passphrase, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s not set", name)
+		}
+		return passphrase, nil
+	}
+}
+
+// PassphraseFromFile reads the passphrase from the first line of the given file.
+func PassphraseFromFile(path string) PassphraseFunc {
+	return func(address common.Address) (string, error) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("error reading passphrase file: %v", err)
+		}
+		passphrase, _, _ := strings.Cut(string(content), "\n")
+		return strings.TrimRight(passphrase, "\r"), nil
+	}
+}
+
+// KeystoreSigner is a Signer backed by an encrypted keystore V3 file.
+// The key is decrypted on first use and zeroed on Close.
+type KeystoreSigner struct {
+	address    common.Address
+	keyjson    []byte
+	passphrase PassphraseFunc
+	mu         sync.Mutex
+	key        *ecdsa.PrivateKey
+	closed     bool
+}
+
+var _ Signer = (*KeystoreSigner)(nil)
+
+// NewKeystoreSigner creates a KeystoreSigner from the keystore file at the given path.
+func NewKeystoreSigner(path string, passphrase PassphraseFunc) (*KeystoreSigner, error) {
+	keyjson, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading keystore file: %v", err)
+	}
+	return NewKeystoreSignerFromJSON(keyjson, passphrase)
+}
+
+// NewKeystoreSignerFromJSON creates a KeystoreSigner from the keystore JSON content.
+func NewKeystoreSignerFromJSON(keyjson []byte, passphrase PassphraseFunc) (*KeystoreSigner, error) {
+	var header struct {
+		Address string `json:"address"`
+	}
+	if err := json.Unmarshal(keyjson, &header); err != nil {
+		return nil, fmt.Errorf("error parsing keystore: %v", err)
+	}
+	if !common.IsHexAddress(header.Address) {
+		return nil, fmt.Errorf("invalid keystore address: %q", header.Address)
+	}
+	return &KeystoreSigner{
+		address:    common.HexToAddress(header.Address),
+		keyjson:    keyjson,
+		passphrase: passphrase,
+	}, nil
+}
+
+// LoadKeystoreSigners creates a KeystoreSigner for every keystore file in the given directory.
+// Hidden files and sub directories are skipped, it returns an error naming the first file
+// that can't be read or isn't a keystore.
+func LoadKeystoreSigners(dir string, passphrase PassphraseFunc) ([]*KeystoreSigner, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading keystore directory: %v", err)
+	}
+	var signers []*KeystoreSigner
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		signer, err := NewKeystoreSigner(path, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("error loading keystore %s: %w", path, err)
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("no keystore found in %s", dir)
+	}
+	return signers, nil
+}
+
+// Address implements Signer.
+func (s *KeystoreSigner) Address() common.Address {
+	return s.address
+}
+
+// SignHash implements Signer.
+func (s *KeystoreSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	key, err := s.PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKeySigner(key).SignHash(ctx, hash)
+}
+
+// SignTypedData implements Signer.
+func (s *KeystoreSigner) SignTypedData(ctx context.Context, typedData apitypes.TypedData) ([]byte, error) {
+	key, err := s.PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKeySigner(key).SignTypedData(ctx, typedData)
+}
+
+// PrivateKey unlocks the keystore if needed and returns the private key.
+func (s *KeystoreSigner) PrivateKey() (*ecdsa.PrivateKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, fmt.Errorf("keystore signer %s is closed", s.address.Hex())
+	}
+	if s.key != nil {
+		return s.key, nil
+	}
+	passphrase, err := s.passphrase(s.address)
+	if err != nil {
+		return nil, fmt.Errorf("error getting passphrase for %s: %v", s.address.Hex(), err)
+	}
+	key, err := keystore.DecryptKey(s.keyjson, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting keystore %s: %v", s.address.Hex(), err)
+	}
+	if key.Address != s.address {
+		zeroKey(key.PrivateKey)
+		return nil, fmt.Errorf("keystore address mismatch: %s != %s", key.Address.Hex(), s.address.Hex())
+	}
+	s.key = key.PrivateKey
+	return s.key, nil
+}
+
+// Close zeroes the unlocked key, the signer can't be used afterwards.
+func (s *KeystoreSigner) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.key != nil {
+		zeroKey(s.key)
+		s.key = nil
+	}
+	s.closed = true
+	return nil
+}
+
+// KeystoreRotator rotates executor keys loaded from keystores in round robin order.
+// Keys are unlocked when they are first returned by Next.
+type KeystoreRotator struct {
+	signers []*KeystoreSigner
+	keys    []*ecdsa.PrivateKey // the added keys, nil for the signers
+	index   atomic.Uint32
+	mu      sync.RWMutex
+	closed  bool
+}
+
+var _ Rotator[*ecdsa.PrivateKey] = (*KeystoreRotator)(nil)
+
+// NewKeystoreRotator creates a KeystoreRotator from the given keystore signers.
+func NewKeystoreRotator(signers []*KeystoreSigner) *KeystoreRotator {
+	return &KeystoreRotator{
+		signers: signers,
+		keys:    make([]*ecdsa.PrivateKey, len(signers)),
+	}
+}
+
+// Next implements Rotator.
+// It returns nil if there is no key, the rotator is closed or the key can't be unlocked, see NextKey for the error.
+func (r *KeystoreRotator) Next() *ecdsa.PrivateKey {
+	key, _ := r.NextKey()
+	return key
+}
+
+// NextKey returns the next key, unlocking it if needed.
+// The key of a signer is asked to the signer on each call, so a signer closed elsewhere fails instead of
+// returning its zeroed key.
+func (r *KeystoreRotator) NextKey() (*ecdsa.PrivateKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, fmt.Errorf("keystore rotator is closed")
+	}
+	if len(r.keys) == 0 {
+		return nil, fmt.Errorf("no key in keystore rotator")
+	}
+	current := r.index.Load()
+	r.index.Store((current + 1) % uint32(len(r.keys)))
+	if signer := r.signers[current]; signer != nil {
+		key, err := signer.PrivateKey()
+		if err != nil {
+			return nil, fmt.Errorf("error unlocking key %d: %w", current, err)
+		}
+		return key, nil
+	}
+	if r.keys[current] == nil {
+		return nil, fmt.Errorf("no key at index %d", current)
+	}
+	return r.keys[current], nil
+}
+
+// Add implements Rotator.
+// The added key is zeroed on Close as well.
+func (r *KeystoreRotator) Add(signer *ecdsa.PrivateKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return fmt.Errorf("keystore rotator is closed")
+	}
+	if signer == nil {
+		return fmt.Errorf("nil key")
+	}
+
+	r.signers = append(r.signers, nil)
+	r.keys = append(r.keys, signer)
+	return nil
+}
+
+// Count implements Rotator.
+func (r *KeystoreRotator) Count() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.keys)
+}
+
+// Close zeroes all unlocked keys, Next returns nil afterwards.
+func (r *KeystoreRotator) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+
+	for i, key := range r.keys {
+		if r.signers[i] != nil {
+			r.signers[i].Close()
+		} else if key != nil {
+			zeroKey(key)
+		}
+		r.keys[i] = nil
+	}
+	return nil
+}
+
+// zeroKey overwrites the private key scalar in memory.
+func zeroKey(key *ecdsa.PrivateKey) {
+	if key == nil || key.D == nil {
+		return
+	}
+	clear(key.D.Bits())
+}
@@ -0,0 +1,203 @@
+package aasdk
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// writeKeystore encrypts a new key into the given directory and returns its address.
+func writeKeystore(t *testing.T, dir string, passphrase string) common.Address {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	keyjson, err := keystore.EncryptKey(&keystore.Key{Address: address, PrivateKey: key}, passphrase, keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatalf("Failed to encrypt key: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, address.Hex()+".json"), keyjson, 0600); err != nil {
+		t.Fatalf("Failed to write keystore: %v", err)
+	}
+	return address
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	dir := t.TempDir()
+	address := writeKeystore(t, dir, "secret")
+
+	calls := 0
+	signers, err := LoadKeystoreSigners(dir, func(common.Address) (string, error) {
+		calls++
+		return "secret", nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to load keystores: %v", err)
+	}
+	if len(signers) != 1 || signers[0].Address() != address {
+		t.Fatalf("Expected keystore for %s, got %v", address.Hex(), signers)
+	}
+	if calls != 0 {
+		t.Errorf("Expected keystore to be unlocked lazily, got %d passphrase calls", calls)
+	}
+
+	hash := crypto.Keccak256([]byte("hash"))
+	for i := 0; i < 2; i++ {
+		if _, err := signers[0].SignHash(context.Background(), hash); err != nil {
+			t.Fatalf("Failed to sign hash: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected keystore to be unlocked once, got %d passphrase calls", calls)
+	}
+
+	signers[0].Close()
+	if _, err := signers[0].SignHash(context.Background(), hash); err == nil {
+		t.Error("Expected error when signing with closed keystore")
+	}
+}
+
+func TestKeystoreRotator(t *testing.T) {
+	dir := t.TempDir()
+	writeKeystore(t, dir, "secret")
+	writeKeystore(t, dir, "secret")
+
+	signers, err := LoadKeystoreSigners(dir, func(common.Address) (string, error) {
+		return "secret", nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to load keystores: %v", err)
+	}
+	rotator := NewKeystoreRotator(signers)
+	if rotator.Count() != 2 {
+		t.Fatalf("Expected count 2, got %d", rotator.Count())
+	}
+	for i := 0; i < 4; i++ {
+		key := rotator.Next()
+		if key == nil {
+			t.Fatalf("Expected unlocked key at rotation %d", i)
+		}
+		if address := crypto.PubkeyToAddress(key.PublicKey); address != signers[i%2].Address() {
+			t.Errorf("Rotation %d: expected %s, got %s", i, signers[i%2].Address().Hex(), address.Hex())
+		}
+	}
+
+	// A signer closed elsewhere isn't handed out zeroed
+	signers[1].Close()
+	if key, err := rotator.NextKey(); err != nil || key == nil {
+		t.Errorf("Expected the open signer key, got %v, error %v", key, err)
+	}
+	if key, err := rotator.NextKey(); err == nil || key != nil {
+		t.Errorf("Expected an error for the closed signer, got key %v", key)
+	}
+
+	rotator.Close()
+	if key := rotator.Next(); key != nil {
+		t.Error("Expected nil key after close")
+	}
+}
+
+func TestKeystoreSignerWrongPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	writeKeystore(t, dir, "secret")
+
+	signers, err := LoadKeystoreSigners(dir, PassphraseFromEnv("AASDK_TEST_UNSET_PASSPHRASE"))
+	if err != nil {
+		t.Fatalf("Failed to load keystores: %v", err)
+	}
+	if _, err := signers[0].PrivateKey(); err == nil {
+		t.Error("Expected error for missing passphrase")
+	}
+}
+
+func TestLoadKeystoreSignersErrors(t *testing.T) {
+	passphrase := func(common.Address) (string, error) {
+		return "secret", nil
+	}
+	dir := t.TempDir()
+	writeKeystore(t, dir, "secret")
+
+	// Hidden files and sub directories are skipped
+	if err := os.WriteFile(filepath.Join(dir, ".DS_Store"), []byte("not a keystore"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "backup"), 0700); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if signers, err := LoadKeystoreSigners(dir, passphrase); err != nil || len(signers) != 1 {
+		t.Fatalf("Expected one keystore, got %d, error %v", len(signers), err)
+	}
+
+	// A file that isn't a keystore is reported instead of shrinking the rotation
+	invalid := filepath.Join(dir, "executor.json")
+	if err := os.WriteFile(invalid, []byte("{"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := LoadKeystoreSigners(dir, passphrase); err == nil || !strings.Contains(err.Error(), invalid) {
+		t.Errorf("Expected error naming %s, got %v", invalid, err)
+	}
+
+	// A file that can't be read is reported, e.g. a dangling link
+	if err := os.Remove(invalid); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	dangling := filepath.Join(dir, "missing.json")
+	if err := os.Symlink(filepath.Join(dir, "nowhere"), dangling); err != nil {
+		t.Fatalf("Failed to create link: %v", err)
+	}
+	if _, err := LoadKeystoreSigners(dir, passphrase); err == nil || !strings.Contains(err.Error(), dangling) {
+		t.Errorf("Expected error naming %s, got %v", dangling, err)
+	}
+
+	if _, err := LoadKeystoreSigners(t.TempDir(), passphrase); err == nil {
+		t.Error("Expected error for an empty directory")
+	}
+}
+
+func TestKeystoreRotatorAdd(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	rotator := NewKeystoreRotator(nil)
+	if err := rotator.Add(key); err != nil {
+		t.Fatalf("Failed to add key: %v", err)
+	}
+	if next := rotator.Next(); next != key {
+		t.Fatalf("Expected added key, got %v", next)
+	}
+
+	rotator.Close()
+	if next := rotator.Next(); next != nil {
+		t.Error("Expected nil key after close")
+	}
+	for _, word := range key.D.Bits() {
+		if word != 0 {
+			t.Fatal("Expected added key to be zeroed on close")
+		}
+	}
+	if err := rotator.Add(key); err == nil {
+		t.Error("Expected error adding a key after close")
+	}
+}
+
+func TestKeystoreRotatorUnlockError(t *testing.T) {
+	signer, err := NewKeystoreSignerFromJSON([]byte(`{"address":"0000000000000000000000000000000000000001"}`), PassphraseFromEnv("AASDK_TEST_UNSET_PASSPHRASE"))
+	if err != nil {
+		t.Fatalf("Failed to create keystore signer: %v", err)
+	}
+	rotator := NewKeystoreRotator([]*KeystoreSigner{signer})
+	if key, err := rotator.NextKey(); err == nil || key != nil {
+		t.Errorf("Expected unlock error, got key %v, error %v", key, err)
+	}
+	if _, err := nextKey(rotator); err == nil {
+		t.Error("Expected unlock error to be reported")
+	}
+}
@@ -3,6 +3,7 @@ package aasdk
 import (
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
@@ -18,8 +19,56 @@ const (
 
 var (
 	EmptySignature = make([]byte, 65)
+
+	// DefaultPaymasterValidity is how long the verifying paymaster signatures are valid after signing.
+	DefaultPaymasterValidity = 10 * time.Minute
 )
 
+// paymasterValidityLength is the length of the abi encoded validUntil and validAfter in the paymaster data.
+const paymasterValidityLength = 64
+
+// PaymasterValidity is the validity window of a verifying paymaster signature.
+// A zero ValidUntil never expires, a zero ValidAfter is valid immediately.
+type PaymasterValidity struct {
+	ValidUntil time.Time
+	ValidAfter time.Time
+}
+
+// NewPaymasterValidity returns a window valid immediately and until the duration from now.
+// ValidAfter is left zero, the block timestamp may lag behind the local clock.
+func NewPaymasterValidity(d time.Duration) PaymasterValidity {
+	return PaymasterValidity{ValidUntil: time.Now().Truncate(time.Second).Add(d)}
+}
+
+// Expired checks if the signature is expired at the time.
+func (v PaymasterValidity) Expired(at time.Time) bool {
+	return !v.ValidUntil.IsZero() && at.After(v.ValidUntil)
+}
+
+// Active checks if the signature is valid at the time.
+func (v PaymasterValidity) Active(at time.Time) bool {
+	return !v.Expired(at) && !at.Before(v.ValidAfter)
+}
+
+// Ints returns validUntil and validAfter as the uint48 timestamps of the paymaster data.
+func (v PaymasterValidity) Ints() (*big.Int, *big.Int) {
+	return unixInt(v.ValidUntil), unixInt(v.ValidAfter)
+}
+
+func unixInt(t time.Time) *big.Int {
+	if t.IsZero() {
+		return new(big.Int)
+	}
+	return big.NewInt(t.Unix())
+}
+
+func unixTime(i *big.Int) time.Time {
+	if i.Sign() == 0 {
+		return time.Time{}
+	}
+	return time.Unix(i.Int64(), 0)
+}
+
 // GetPaymasterHash returns the hash to sign for a user operation
 func GetPaymasterHash(
 	packedUserOp *entrypoint.PackedUserOperation,
@@ -83,6 +132,18 @@ func PackPaymasterAndData(paymaster common.Address, verGasLimit, postOpGasLimit
 	return result
 }
 
+// UnpackPaymasterAndData is the inverse of PackPaymasterAndData.
+// It returns an error if paymasterAndData is shorter than PaymasterDataOffset.
+func UnpackPaymasterAndData(paymasterAndData []byte) (common.Address, *big.Int, *big.Int, []byte, error) {
+	if len(paymasterAndData) < PaymasterDataOffset {
+		return common.Address{}, nil, nil, nil, fmt.Errorf("paymasterAndData too short: %d bytes, expected at least %d", len(paymasterAndData), PaymasterDataOffset)
+	}
+	paymaster := common.BytesToAddress(paymasterAndData[:PaymasterValidationGasOffset])
+	verGasLimit := new(big.Int).SetBytes(paymasterAndData[PaymasterValidationGasOffset:PaymasterPostOpGasOffset])
+	postOpGasLimit := new(big.Int).SetBytes(paymasterAndData[PaymasterPostOpGasOffset:PaymasterDataOffset])
+	return paymaster, verGasLimit, postOpGasLimit, paymasterAndData[PaymasterDataOffset:], nil
+}
+
 // EncodePaymasterData encodes validUntil, validAfter, and signature into a byte array
 func EncodePaymasterData(validUntil, validAfter *big.Int, signature []byte) ([]byte, error) {
 	data, err := abi.Arguments{
@@ -98,3 +159,26 @@ func EncodePaymasterData(validUntil, validAfter *big.Int, signature []byte) ([]b
 	data = append(data, signature...)
 	return data, nil
 }
+
+// DecodePaymasterData is the inverse of EncodePaymasterData, it returns the validity window and the signature.
+func DecodePaymasterData(paymasterData []byte) (PaymasterValidity, []byte, error) {
+	if len(paymasterData) < paymasterValidityLength {
+		return PaymasterValidity{}, nil, fmt.Errorf("paymaster data too short: %d bytes, expected at least %d", len(paymasterData), paymasterValidityLength)
+	}
+	validUntil := new(big.Int).SetBytes(paymasterData[:32])
+	validAfter := new(big.Int).SetBytes(paymasterData[32:paymasterValidityLength])
+	if validUntil.BitLen() > 48 || validAfter.BitLen() > 48 {
+		return PaymasterValidity{}, nil, fmt.Errorf("paymaster validity out of uint48 range")
+	}
+	validity := PaymasterValidity{ValidUntil: unixTime(validUntil), ValidAfter: unixTime(validAfter)}
+	return validity, paymasterData[paymasterValidityLength:], nil
+}
+
+// PaymasterValidity returns the validity window of the verifying paymaster signature of the user operation.
+func (u *UserOperation) PaymasterValidity() (PaymasterValidity, error) {
+	if u.Paymaster == (common.Address{}) {
+		return PaymasterValidity{}, fmt.Errorf("no paymaster")
+	}
+	validity, _, err := DecodePaymasterData(u.PaymasterData)
+	return validity, err
+}
@@ -0,0 +1,127 @@
+package aasdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	// The ERC-7677 paymaster service methods.
+	PaymasterStubDataMethod = "pm_getPaymasterStubData"
+	PaymasterDataMethod     = "pm_getPaymasterData"
+)
+
+type PaymasterClientConfig struct {
+	// The url of the paymaster service.
+	Url string
+	// The headers added to every request, e.g. API keys.
+	Headers map[string]string
+	// The http client, defaults to a client with DefaultRequestTimeout.
+	HttpClient *http.Client
+}
+
+// PaymasterClient is an ERC-7677 paymaster service client.
+type PaymasterClient struct {
+	transport *transport
+}
+
+var _ PaymasterProvider = (*PaymasterClient)(nil)
+
+// NewPaymasterClient creates a new PaymasterClient with given config.
+func NewPaymasterClient(config PaymasterClientConfig) (*PaymasterClient, error) {
+	if config.Url == "" {
+		return nil, fmt.Errorf("paymaster service url is empty")
+	}
+	return &PaymasterClient{
+		transport: newTransport(config.Url, config.Headers, config.HttpClient),
+	}, nil
+}
+
+// rpcPaymasterResult is the result of the ERC-7677 methods, for both V0.6.0 and V0.7.0 entrypoints.
+type rpcPaymasterResult struct {
+	Paymaster                     *common.Address   `json:"paymaster,omitempty"`
+	PaymasterData                 hexutil.Bytes     `json:"paymasterData,omitempty"`
+	PaymasterAndData              hexutil.Bytes     `json:"paymasterAndData,omitempty"`
+	PaymasterVerificationGasLimit *hexutil.Big      `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       *hexutil.Big      `json:"paymasterPostOpGasLimit,omitempty"`
+	Sponsor                       *PaymasterSponsor `json:"sponsor,omitempty"`
+	IsFinal                       bool              `json:"isFinal,omitempty"`
+}
+
+// toResult returns the paymaster fields, splitting the V0.6.0 paymasterAndData.
+func (r *rpcPaymasterResult) toResult() (*PaymasterResult, error) {
+	if r.Paymaster != nil {
+		return &PaymasterResult{Paymaster: *r.Paymaster, PaymasterData: r.PaymasterData}, nil
+	}
+	if len(r.PaymasterAndData) < common.AddressLength {
+		return nil, fmt.Errorf("no paymaster in result")
+	}
+	return &PaymasterResult{
+		Paymaster:     common.BytesToAddress(r.PaymasterAndData[:common.AddressLength]),
+		PaymasterData: r.PaymasterAndData[common.AddressLength:],
+	}, nil
+}
+
+// GetPaymasterStubData implements PaymasterProvider with pm_getPaymasterStubData.
+func (p *PaymasterClient) GetPaymasterStubData(ctx context.Context, req *PaymasterRequest) (*PaymasterStubResult, error) {
+	result, err := p.call(ctx, PaymasterStubDataMethod, req)
+	if err != nil {
+		return nil, err
+	}
+	paymaster, err := result.toResult()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s result: %v", PaymasterStubDataMethod, err)
+	}
+	return &PaymasterStubResult{
+		PaymasterResult:               *paymaster,
+		PaymasterVerificationGasLimit: (*big.Int)(result.PaymasterVerificationGasLimit),
+		PaymasterPostOpGasLimit:       (*big.Int)(result.PaymasterPostOpGasLimit),
+		Sponsor:                       result.Sponsor,
+		IsFinal:                       result.IsFinal,
+	}, nil
+}
+
+// GetPaymasterData implements PaymasterProvider with pm_getPaymasterData.
+func (p *PaymasterClient) GetPaymasterData(ctx context.Context, req *PaymasterRequest) (*PaymasterResult, error) {
+	result, err := p.call(ctx, PaymasterDataMethod, req)
+	if err != nil {
+		return nil, err
+	}
+	paymaster, err := result.toResult()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s result: %v", PaymasterDataMethod, err)
+	}
+	return paymaster, nil
+}
+
+// call calls the method with [userOp, entrypoint, chainId, context].
+func (p *PaymasterClient) call(ctx context.Context, method string, req *PaymasterRequest) (*rpcPaymasterResult, error) {
+	userOp := userOpBody(req.UserOp, req.EntrypointVersion)
+	paymasterContext := req.Context
+	if paymasterContext == nil {
+		paymasterContext = map[string]any{}
+	}
+	params := []any{userOp, req.Entrypoint, (*hexutil.Big)(req.ChainId), paymasterContext}
+
+	bytes, err := p.transport.call(ctx, method, params)
+	if err != nil {
+		return nil, fmt.Errorf("error calling %s: %w", method, err)
+	}
+	var response jsonRpcResponse[*rpcPaymasterResult]
+	if err = json.Unmarshal(bytes, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshalling %s response: %v", method, err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("error from paymaster: %w", response.Error.toError())
+	}
+	if response.Result == nil {
+		return nil, fmt.Errorf("empty %s result", method)
+	}
+	return response.Result, nil
+}
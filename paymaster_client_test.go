@@ -0,0 +1,66 @@
+package aasdk
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestPaymasterClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Id     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if len(request.Params) != 4 || string(request.Params[2]) != `"0x1"` || string(request.Params[3]) != `{"policyId":"abc"}` {
+			t.Errorf("Unexpected params %s", request.Params)
+		}
+		var result string
+		switch request.Method {
+		case PaymasterStubDataMethod:
+			result = `{"paymaster":"0x00000000000000000000000000000000000000bb","paymasterData":"0x01","paymasterPostOpGasLimit":"0x10","sponsor":{"name":"Sponsor"}}`
+		case PaymasterDataMethod:
+			result = `{"paymasterAndData":"0x00000000000000000000000000000000000000bb02"}`
+		}
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": request.Id, "result": json.RawMessage(result)})
+	}))
+	defer server.Close()
+
+	client, err := NewPaymasterClient(PaymasterClientConfig{Url: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create paymaster client: %v", err)
+	}
+	req := &PaymasterRequest{
+		UserOp:  &UserOperation{Sender: common.HexToAddress("0x01")},
+		ChainId: big.NewInt(1),
+		Context: map[string]any{"policyId": "abc"},
+	}
+	stub, err := client.GetPaymasterStubData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Failed to get paymaster stub data: %v", err)
+	}
+	if stub.Paymaster != common.HexToAddress("0xbb") || common.Bytes2Hex(stub.PaymasterData) != "01" || stub.IsFinal {
+		t.Errorf("Unexpected stub data %+v", stub)
+	}
+	if stub.PaymasterPostOpGasLimit.Int64() != 0x10 || stub.PaymasterVerificationGasLimit != nil || stub.Sponsor.Name != "Sponsor" {
+		t.Errorf("Unexpected stub gas limits or sponsor %+v", stub)
+	}
+
+	// The V0.6.0 paymasterAndData is split
+	data, err := client.GetPaymasterData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Failed to get paymaster data: %v", err)
+	}
+	if data.Paymaster != common.HexToAddress("0xbb") || common.Bytes2Hex(data.PaymasterData) != "02" {
+		t.Errorf("Unexpected paymaster data %+v", data)
+	}
+}
@@ -0,0 +1,229 @@
+package aasdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/account"
+)
+
+var (
+	// ErrSponsorshipRejected is wrapped by the errors of the sponsorship policies.
+	ErrSponsorshipRejected = errors.New("sponsorship rejected")
+)
+
+// SponsorshipPolicy decides whether a paymaster server sponsors a user operation.
+type SponsorshipPolicy interface {
+	// Check returns an error wrapping ErrSponsorshipRejected if the user operation must not be sponsored.
+	Check(ctx context.Context, req *PaymasterRequest) error
+}
+
+// SponsorshipRecorder is implemented by the policies that track the sponsored user operations.
+type SponsorshipRecorder interface {
+	// Reserve atomically checks and records the user operation before its paymaster data is signed.
+	// It returns an error wrapping ErrSponsorshipRejected if the user operation must not be sponsored,
+	// or a function undoing the reservation if the signing fails.
+	Reserve(ctx context.Context, req *PaymasterRequest) (release func(), err error)
+}
+
+// SponsorshipPolicyFunc is a SponsorshipPolicy function.
+type SponsorshipPolicyFunc func(ctx context.Context, req *PaymasterRequest) error
+
+// Check implements SponsorshipPolicy.
+func (f SponsorshipPolicyFunc) Check(ctx context.Context, req *PaymasterRequest) error {
+	return f(ctx, req)
+}
+
+// AllowedSenders only sponsors the user operations of the senders.
+func AllowedSenders(senders ...common.Address) SponsorshipPolicy {
+	allowed := make(map[common.Address]bool, len(senders))
+	for _, sender := range senders {
+		allowed[sender] = true
+	}
+	return SponsorshipPolicyFunc(func(ctx context.Context, req *PaymasterRequest) error {
+		if !allowed[req.UserOp.Sender] {
+			return fmt.Errorf("%w: sender %s not allowed", ErrSponsorshipRejected, req.UserOp.Sender.Hex())
+		}
+		return nil
+	})
+}
+
+// AllowedTargets only sponsors the user operations calling the targets,
+// through the execute and executeBatch methods of SimpleAccount.
+func AllowedTargets(targets ...common.Address) SponsorshipPolicy {
+	allowed := make(map[common.Address]bool, len(targets))
+	for _, target := range targets {
+		allowed[target] = true
+	}
+	return SponsorshipPolicyFunc(func(ctx context.Context, req *PaymasterRequest) error {
+		calls, err := callTargets(req.UserOp.CallData)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrSponsorshipRejected, err)
+		}
+		for _, target := range calls {
+			if !allowed[target] {
+				return fmt.Errorf("%w: target %s not allowed", ErrSponsorshipRejected, target.Hex())
+			}
+		}
+		return nil
+	})
+}
+
+// SponsorshipWindow only sponsors the user operations between start and end, a zero time is unbounded.
+func SponsorshipWindow(start, end time.Time) SponsorshipPolicy {
+	return SponsorshipPolicyFunc(func(ctx context.Context, req *PaymasterRequest) error {
+		now := time.Now()
+		if !start.IsZero() && now.Before(start) {
+			return fmt.Errorf("%w: sponsorship starts at %s", ErrSponsorshipRejected, start.Format(time.RFC3339))
+		}
+		if !end.IsZero() && !now.Before(end) {
+			return fmt.Errorf("%w: sponsorship ended at %s", ErrSponsorshipRejected, end.Format(time.RFC3339))
+		}
+		return nil
+	})
+}
+
+// SpendCap caps the maximum gas cost sponsored per sender, as given by RequiredPrefund.
+// The spending is tracked in memory and resets every period, an operation signed again
+// with the same nonce only counts once.
+type SpendCap struct {
+	cap    *big.Int
+	period time.Duration
+
+	mu    sync.Mutex
+	spent map[common.Address]*senderSpend
+}
+
+type senderSpend struct {
+	amount *big.Int
+	since  time.Time
+	ops    map[string]*big.Int // the amount of each nonce
+}
+
+var (
+	_ SponsorshipPolicy   = (*SpendCap)(nil)
+	_ SponsorshipRecorder = (*SpendCap)(nil)
+)
+
+// NewSpendCap creates a SpendCap of cap wei per sender and period, 0 never resets.
+func NewSpendCap(cap *big.Int, period time.Duration) *SpendCap {
+	return &SpendCap{
+		cap:    cap,
+		period: period,
+		spent:  make(map[common.Address]*senderSpend),
+	}
+}
+
+// Check implements SponsorshipPolicy.
+func (s *SpendCap) Check(ctx context.Context, req *PaymasterRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _, err := s.check(req)
+	return err
+}
+
+// Reserve implements SponsorshipRecorder.
+func (s *SpendCap) Reserve(ctx context.Context, req *PaymasterRequest) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	spend, amount, err := s.check(req)
+	if err != nil {
+		return nil, err
+	}
+	nonce := nonceKey(req.UserOp.Nonce)
+	previous := spend.ops[nonce]
+	spend.amount.Add(spend.amount, amount)
+	if previous != nil {
+		spend.amount.Sub(spend.amount, previous)
+	}
+	spend.ops[nonce] = amount
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// The spending was reset or replaced since the reservation.
+		if s.spent[req.UserOp.Sender] != spend || spend.ops[nonce] != amount {
+			return
+		}
+		spend.amount.Sub(spend.amount, amount)
+		if previous != nil {
+			spend.amount.Add(spend.amount, previous)
+			spend.ops[nonce] = previous
+		} else {
+			delete(spend.ops, nonce)
+		}
+	}, nil
+}
+
+// Spent returns the amount sponsored for the sender in the current period.
+func (s *SpendCap) Spent(sender common.Address) *big.Int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return new(big.Int).Set(s.current(sender).amount)
+}
+
+// check returns the spending of the sender and the amount of the user operation,
+// or an error if the cap would be exceeded, s.mu must be held.
+func (s *SpendCap) check(req *PaymasterRequest) (*senderSpend, *big.Int, error) {
+	spend := s.current(req.UserOp.Sender)
+	amount := RequiredPrefund(req.UserOp, req.EntrypointVersion)
+	spent := new(big.Int).Add(spend.amount, amount)
+	if previous := spend.ops[nonceKey(req.UserOp.Nonce)]; previous != nil {
+		spent.Sub(spent, previous)
+	}
+	if spent.Cmp(s.cap) > 0 {
+		return nil, nil, fmt.Errorf("%w: spend cap of %s exceeded by sender %s", ErrSponsorshipRejected, s.cap, req.UserOp.Sender.Hex())
+	}
+	return spend, amount, nil
+}
+
+// current returns the spending of the sender in the current period, s.mu must be held.
+func (s *SpendCap) current(sender common.Address) *senderSpend {
+	now := time.Now()
+	spend, ok := s.spent[sender]
+	if !ok || (s.period > 0 && now.Sub(spend.since) >= s.period) {
+		spend = &senderSpend{amount: new(big.Int), since: now, ops: make(map[string]*big.Int)}
+		s.spent[sender] = spend
+	}
+	return spend
+}
+
+// nonceKey returns the key of the nonce in senderSpend.ops.
+func nonceKey(nonce *big.Int) string {
+	if nonce == nil {
+		return "0"
+	}
+	return nonce.String()
+}
+
+// callTargets returns the targets called by the SimpleAccount calldata.
+func callTargets(callData []byte) ([]common.Address, error) {
+	accountABI, err := account.SimpleAccountMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("error getting simple account ABI: %v", err)
+	}
+	if len(callData) < 4 {
+		return nil, fmt.Errorf("calldata too short")
+	}
+	method, err := accountABI.MethodById(callData[:4])
+	if err != nil {
+		return nil, fmt.Errorf("unknown account method")
+	}
+	args, err := method.Inputs.Unpack(callData[4:])
+	if err != nil {
+		return nil, fmt.Errorf("error unpacking %s calldata: %v", method.Name, err)
+	}
+	switch method.Name {
+	case "execute":
+		return []common.Address{args[0].(common.Address)}, nil
+	case "executeBatch":
+		return args[0].([]common.Address), nil
+	default:
+		return nil, fmt.Errorf("unexpected account method %s", method.Name)
+	}
+}
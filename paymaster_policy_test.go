@@ -0,0 +1,82 @@
+package aasdk
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/account"
+)
+
+func TestAllowedTargets(t *testing.T) {
+	accountABI, err := account.SimpleAccountMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to get account ABI: %v", err)
+	}
+	allowed, other := common.HexToAddress("0xaa"), common.HexToAddress("0xbb")
+	execute, err := PackTransferData(accountABI, allowed, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("Failed to pack execute: %v", err)
+	}
+	batch, err := PackBatchTransferData(accountABI, []common.Address{allowed, other}, []*big.Int{big.NewInt(1), big.NewInt(2)})
+	if err != nil {
+		t.Fatalf("Failed to pack executeBatch: %v", err)
+	}
+
+	targets, err := callTargets(batch)
+	if err != nil {
+		t.Fatalf("Failed to get call targets: %v", err)
+	}
+	if len(targets) != 2 || targets[0] != allowed || targets[1] != other {
+		t.Errorf("Unexpected batch targets %v", targets)
+	}
+
+	policy := AllowedTargets(allowed)
+	tests := []struct {
+		name     string
+		callData []byte
+		rejected bool
+	}{
+		{"execute", execute, false},
+		{"executeBatch with a target not allowed", batch, true},
+		{"unknown method", []byte{0xde, 0xad, 0xbe, 0xef}, true},
+		{"short calldata", []byte{0x01}, true},
+	}
+	for _, test := range tests {
+		req := &PaymasterRequest{UserOp: &UserOperation{CallData: test.callData}}
+		err := policy.Check(context.Background(), req)
+		if test.rejected && !errors.Is(err, ErrSponsorshipRejected) {
+			t.Errorf("%s: expected ErrSponsorshipRejected, got %v", test.name, err)
+		}
+		if !test.rejected && err != nil {
+			t.Errorf("%s: unexpected rejection: %v", test.name, err)
+		}
+	}
+}
+
+func TestSponsorshipWindow(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		start, end time.Time
+		rejected   bool
+	}{
+		{"unbounded", time.Time{}, time.Time{}, false},
+		{"open", now.Add(-time.Hour), now.Add(time.Hour), false},
+		{"not started", now.Add(time.Hour), time.Time{}, true},
+		{"ended", time.Time{}, now.Add(-time.Hour), true},
+	}
+	req := &PaymasterRequest{UserOp: &UserOperation{}}
+	for _, test := range tests {
+		err := SponsorshipWindow(test.start, test.end).Check(context.Background(), req)
+		if test.rejected && !errors.Is(err, ErrSponsorshipRejected) {
+			t.Errorf("%s: expected ErrSponsorshipRejected, got %v", test.name, err)
+		}
+		if !test.rejected && err != nil {
+			t.Errorf("%s: unexpected rejection: %v", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package aasdk
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypoint"
+)
+
+// PaymasterProvider provides the paymaster fields of user operations, e.g. an ERC-7677 paymaster service.
+type PaymasterProvider interface {
+	// GetPaymasterStubData returns the paymaster fields used while estimating the gas of the user operation.
+	GetPaymasterStubData(ctx context.Context, req *PaymasterRequest) (*PaymasterStubResult, error)
+
+	// GetPaymasterData returns the final paymaster fields of the user operation, filled with its gas limits and fees.
+	GetPaymasterData(ctx context.Context, req *PaymasterRequest) (*PaymasterResult, error)
+}
+
+// PaymasterRequest is a request for the paymaster fields of an unsigned user operation.
+type PaymasterRequest struct {
+	UserOp            *UserOperation
+	Entrypoint        common.Address
+	EntrypointVersion EntryPointVersion
+	ChainId           *big.Int
+	// The provider specific context, e.g. a sponsorship policy id.
+	Context map[string]any
+	// The validity window of the paymaster signature. It's optional and only used by VerifyingPaymaster.
+	Validity *PaymasterValidity
+}
+
+// PaymasterSponsor is the sponsor of a user operation, for display.
+type PaymasterSponsor struct {
+	Name string `json:"name"`
+	Icon string `json:"icon,omitempty"`
+}
+
+// PaymasterResult is the paymaster fields of a user operation.
+type PaymasterResult struct {
+	Paymaster     common.Address
+	PaymasterData []byte
+}
+
+// PaymasterStubResult is the paymaster fields of a user operation while estimating its gas.
+type PaymasterStubResult struct {
+	PaymasterResult
+	// The paymaster gas limits, the estimates of the bundler are used when nil.
+	PaymasterVerificationGasLimit *big.Int
+	PaymasterPostOpGasLimit       *big.Int
+	Sponsor                       *PaymasterSponsor
+	// Whether the stub data is final, GetPaymasterData is then skipped.
+	IsFinal bool
+}
+
+// applyGasLimits sets the paymaster gas limits of the stub on the user operation, if any.
+func (r *PaymasterStubResult) applyGasLimits(userOp *UserOperation) {
+	if r.PaymasterVerificationGasLimit != nil {
+		userOp.PaymasterVerificationGasLimit = r.PaymasterVerificationGasLimit
+	}
+	if r.PaymasterPostOpGasLimit != nil {
+		userOp.PaymasterPostOpGasLimit = r.PaymasterPostOpGasLimit
+	}
+}
+
+// VerifyingPaymaster provides the paymaster data of a VerifyingPaymaster, signed with a local signer.
+type VerifyingPaymaster struct {
+	paymaster common.Address
+	signer    Signer
+	validity  time.Duration
+	caller    bind.ContractCaller // reads the V0.6.0 sender nonces, if set
+}
+
+var _ PaymasterProvider = (*VerifyingPaymaster)(nil)
+
+// NewVerifyingPaymaster creates a provider for the VerifyingPaymaster at the address, signing with the verifying signer.
+// The signatures are valid for the validity from signing unless the request has a window,
+// it defaults to DefaultPaymasterValidity.
+func NewVerifyingPaymaster(paymaster common.Address, signer Signer, validity time.Duration) *VerifyingPaymaster {
+	if validity <= 0 {
+		validity = DefaultPaymasterValidity
+	}
+	return &VerifyingPaymaster{
+		paymaster: paymaster,
+		signer:    signer,
+		validity:  validity,
+	}
+}
+
+// NewVerifyingPaymasterV06 is NewVerifyingPaymaster with the node reading the sender nonces of the paymaster,
+// which the EntryPoint V0.6.0 verifying paymaster signs over. The paymaster also serves the other versions.
+func NewVerifyingPaymasterV06(paymaster common.Address, signer Signer, validity time.Duration, caller bind.ContractCaller) *VerifyingPaymaster {
+	p := NewVerifyingPaymaster(paymaster, signer, validity)
+	p.caller = caller
+	return p
+}
+
+// GetPaymasterStubData implements PaymasterProvider, with a dummy signature.
+func (p *VerifyingPaymaster) GetPaymasterStubData(ctx context.Context, req *PaymasterRequest) (*PaymasterStubResult, error) {
+	validUntil, validAfter := p.window(req).Ints()
+	paymasterData, err := EncodePaymasterData(validUntil, validAfter, DummySignature)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding paymaster data: %v", err)
+	}
+	return &PaymasterStubResult{
+		PaymasterResult: PaymasterResult{Paymaster: p.paymaster, PaymasterData: paymasterData},
+	}, nil
+}
+
+// GetPaymasterData implements PaymasterProvider.
+func (p *VerifyingPaymaster) GetPaymasterData(ctx context.Context, req *PaymasterRequest) (*PaymasterResult, error) {
+	if p.signer == nil {
+		return nil, fmt.Errorf("no verifying signer")
+	}
+	validUntil, validAfter := p.window(req).Ints()
+	paymasterData, err := EncodePaymasterData(validUntil, validAfter, EmptySignature)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding paymaster data: %v", err)
+	}
+
+	userOp := req.UserOp
+	var paymasterHash common.Hash
+	if req.EntrypointVersion == EntryPointV06 {
+		if p.caller == nil {
+			return nil, fmt.Errorf("no node to read the V0.6.0 sender nonce, see NewVerifyingPaymasterV06")
+		}
+		senderNonce, err := GetPaymasterSenderNonceV06(ctx, p.caller, p.paymaster, userOp.Sender)
+		if err != nil {
+			return nil, fmt.Errorf("error getting paymaster sender nonce: %v", err)
+		}
+		packed := PackUserOperationV06(&UserOperation{
+			Sender:               userOp.Sender,
+			Nonce:                userOp.Nonce,
+			InitCode:             userOp.InitCode,
+			CallData:             userOp.CallData,
+			CallGasLimit:         userOp.CallGasLimit,
+			VerificationGasLimit: userOp.VerificationGasLimit,
+			PreVerificationGas:   userOp.PreVerificationGas,
+			MaxFeePerGas:         userOp.MaxFeePerGas,
+			MaxPriorityFeePerGas: userOp.MaxPriorityFeePerGas,
+		})
+		paymasterHash, err = GetPaymasterHashV06(&packed, p.paymaster, req.ChainId, senderNonce, validUntil, validAfter)
+	} else {
+		paymasterHash, err = GetPaymasterHash(&entrypoint.PackedUserOperation{
+			Sender:             userOp.Sender,
+			Nonce:              userOp.Nonce,
+			InitCode:           userOp.InitCode,
+			CallData:           userOp.CallData,
+			AccountGasLimits:   PackInt(userOp.VerificationGasLimit, userOp.CallGasLimit),
+			PreVerificationGas: userOp.PreVerificationGas,
+			GasFees:            PackInt(userOp.MaxPriorityFeePerGas, userOp.MaxFeePerGas),
+			PaymasterAndData:   PackPaymasterAndData(p.paymaster, userOp.PaymasterVerificationGasLimit, userOp.PaymasterPostOpGasLimit, paymasterData),
+			Signature:          []byte{},
+		}, req.ChainId, validUntil, validAfter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting paymaster data: %v", err)
+	}
+	paymasterSig, err := SignMessage(ctx, p.signer, paymasterHash.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error signing paymaster data: %v", err)
+	}
+	paymasterData, err = EncodePaymasterData(validUntil, validAfter, paymasterSig)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding paymaster data: %v", err)
+	}
+	return &PaymasterResult{Paymaster: p.paymaster, PaymasterData: paymasterData}, nil
+}
+
+// window returns the validity window of the request, or the default one from now.
+func (p *VerifyingPaymaster) window(req *PaymasterRequest) PaymasterValidity {
+	if req.Validity != nil {
+		return *req.Validity
+	}
+	return NewPaymasterValidity(p.validity)
+}
@@ -0,0 +1,258 @@
+package aasdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	// maxPaymasterRequestSize caps the body of the paymaster server requests.
+	maxPaymasterRequestSize = 1 << 20
+
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInternalError  = -32603
+)
+
+type PaymasterServerConfig struct {
+	// The verifying paymaster address.
+	Paymaster common.Address
+	// The account verifying Paymaster requests.
+	VerifyingSigner Signer
+	// The entrypoint address.
+	Entrypoint common.Address
+	// The version of the entrypoint, defaults to EntryPointV07.
+	EntrypointVersion EntryPointVersion
+	// The chain id.
+	ChainId *big.Int
+	// The node reading the sender nonces of the paymaster, e.g. an ethclient.Client.
+	// It's required with EntryPointV06, as the V0.6.0 paymaster signs over them.
+	Caller bind.ContractCaller
+	// How long the paymaster signatures are valid after signing, defaults to DefaultPaymasterValidity.
+	Validity time.Duration
+	// The policies a user operation must pass to be sponsored, in order. At least one is required unless SponsorAll is set.
+	Policies []SponsorshipPolicy
+	// Whether to sponsor every user operation without policies, e.g. for a testnet.
+	SponsorAll bool
+	// The sponsor returned with the stub data. It's optional.
+	Sponsor *PaymasterSponsor
+	// The paymaster gas limits returned with the stub data.
+	// They're optional, the bundler estimates them when nil.
+	PaymasterVerificationGasLimit *big.Int
+	PaymasterPostOpGasLimit       *big.Int
+	// The logger of the internal errors, which are answered as a generic error. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// PaymasterServer is an http.Handler serving the ERC-7677 methods for a VerifyingPaymaster,
+// signing the user operations that pass the sponsorship policies.
+type PaymasterServer struct {
+	config    PaymasterServerConfig
+	paymaster *VerifyingPaymaster
+}
+
+var _ http.Handler = (*PaymasterServer)(nil)
+
+// NewPaymasterServer creates a new PaymasterServer with given config.
+func NewPaymasterServer(config PaymasterServerConfig) (*PaymasterServer, error) {
+	if config.Paymaster == (common.Address{}) {
+		return nil, fmt.Errorf("paymaster address is empty")
+	}
+	if config.VerifyingSigner == nil {
+		return nil, fmt.Errorf("verifying signer is nil")
+	}
+	if config.ChainId == nil {
+		return nil, fmt.Errorf("chain id is nil")
+	}
+	if config.EntrypointVersion == "" {
+		config.EntrypointVersion = EntryPointV07
+	}
+	if config.EntrypointVersion == EntryPointV06 && config.Caller == nil {
+		return nil, fmt.Errorf("caller is nil, it's required with entrypoint %s", EntryPointV06)
+	}
+	if len(config.Policies) == 0 && !config.SponsorAll {
+		return nil, fmt.Errorf("no sponsorship policy, set SponsorAll to sponsor every user operation")
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+	return &PaymasterServer{
+		config:    config,
+		paymaster: NewVerifyingPaymasterV06(config.Paymaster, config.VerifyingSigner, config.Validity, config.Caller),
+	}, nil
+}
+
+// rpcError is a JSON-RPC error of the paymaster server.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// ServeHTTP implements http.Handler.
+func (s *PaymasterServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request struct {
+		Id     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	var result any
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPaymasterRequestSize))
+	if err == nil {
+		err = json.Unmarshal(body, &request)
+	}
+	if err != nil {
+		err = &rpcError{Code: codeParseError, Message: "parse error"}
+	} else {
+		result, err = s.handle(r, request.Method, request.Params)
+	}
+
+	response := map[string]any{"jsonrpc": jsonrpcVersion, "id": request.Id}
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			// The internal errors may reveal the signer or the node, they're only logged.
+			s.config.Logger.ErrorContext(r.Context(), "Failed to serve paymaster request", "method", request.Method, "err", err)
+			rpcErr = &rpcError{Code: codeInternalError, Message: "internal error"}
+		}
+		response["error"] = rpcErr
+	} else {
+		response["result"] = result
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handle serves a JSON-RPC call, the errors other than *rpcError are internal errors.
+func (s *PaymasterServer) handle(r *http.Request, method string, params []json.RawMessage) (any, error) {
+	if method != PaymasterStubDataMethod && method != PaymasterDataMethod {
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %s not found", method)}
+	}
+	req, err := s.parseRequest(params)
+	if err != nil {
+		return nil, &rpcError{Code: CodeInvalidUserOpFields, Message: err.Error()}
+	}
+	ctx := r.Context()
+	if method == PaymasterStubDataMethod {
+		for _, policy := range s.config.Policies {
+			if err := policy.Check(ctx, req); err != nil {
+				return nil, &rpcError{Code: CodeRejectedByPaymaster, Message: err.Error()}
+			}
+		}
+		stub, err := s.paymaster.GetPaymasterStubData(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		result := s.result(&stub.PaymasterResult)
+		result.Sponsor = s.config.Sponsor
+		if s.config.EntrypointVersion != EntryPointV06 {
+			result.PaymasterVerificationGasLimit = (*hexutil.Big)(s.config.PaymasterVerificationGasLimit)
+			result.PaymasterPostOpGasLimit = (*hexutil.Big)(s.config.PaymasterPostOpGasLimit)
+		}
+		return result, nil
+	}
+
+	if s.config.EntrypointVersion != EntryPointV06 && (req.UserOp.PaymasterVerificationGasLimit == nil || req.UserOp.PaymasterPostOpGasLimit == nil) {
+		return nil, &rpcError{Code: CodeInvalidUserOpFields, Message: "missing paymaster gas limits"}
+	}
+	// The recorders reserve the sponsorship with their check, it's released if the user operation isn't signed.
+	var releases []func()
+	release := func() {
+		for _, release := range releases {
+			release()
+		}
+	}
+	for _, policy := range s.config.Policies {
+		var err error
+		if recorder, ok := policy.(SponsorshipRecorder); ok {
+			var release func()
+			if release, err = recorder.Reserve(ctx, req); err == nil {
+				releases = append(releases, release)
+			}
+		} else {
+			err = policy.Check(ctx, req)
+		}
+		if err != nil {
+			release()
+			return nil, &rpcError{Code: CodeRejectedByPaymaster, Message: err.Error()}
+		}
+	}
+	data, err := s.paymaster.GetPaymasterData(ctx, req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return s.result(data), nil
+}
+
+// parseRequest parses the [userOp, entrypoint, chainId, context] params.
+func (s *PaymasterServer) parseRequest(params []json.RawMessage) (*PaymasterRequest, error) {
+	if len(params) < 3 {
+		return nil, fmt.Errorf("expected [userOp, entryPoint, chainId, context] params")
+	}
+	var (
+		userOp     UserOperation
+		entrypoint common.Address
+		chainId    hexutil.Big
+		context    map[string]any
+	)
+	if err := json.Unmarshal(params[0], &userOp); err != nil {
+		return nil, fmt.Errorf("invalid user operation: %v", err)
+	}
+	if err := json.Unmarshal(params[1], &entrypoint); err != nil {
+		return nil, fmt.Errorf("invalid entrypoint: %v", err)
+	}
+	if err := json.Unmarshal(params[2], &chainId); err != nil {
+		return nil, fmt.Errorf("invalid chain id: %v", err)
+	}
+	if len(params) > 3 {
+		if err := json.Unmarshal(params[3], &context); err != nil {
+			return nil, fmt.Errorf("invalid context: %v", err)
+		}
+	}
+	if entrypoint != s.config.Entrypoint {
+		return nil, fmt.Errorf("unsupported entrypoint %s", entrypoint.Hex())
+	}
+	if chainId.ToInt().Cmp(s.config.ChainId) != 0 {
+		return nil, fmt.Errorf("unsupported chain id %s", chainId.ToInt())
+	}
+	if userOp.Nonce == nil || userOp.CallGasLimit == nil || userOp.VerificationGasLimit == nil ||
+		userOp.PreVerificationGas == nil || userOp.MaxFeePerGas == nil || userOp.MaxPriorityFeePerGas == nil {
+		return nil, fmt.Errorf("missing user operation fields")
+	}
+	// The user operation is sponsored by this paymaster, whatever it was filled with.
+	userOp.Paymaster = s.config.Paymaster
+	return &PaymasterRequest{
+		UserOp:            &userOp,
+		Entrypoint:        entrypoint,
+		EntrypointVersion: s.config.EntrypointVersion,
+		ChainId:           s.config.ChainId,
+		Context:           context,
+	}, nil
+}
+
+// result encodes the paymaster fields for the entrypoint version.
+func (s *PaymasterServer) result(paymaster *PaymasterResult) *rpcPaymasterResult {
+	if s.config.EntrypointVersion == EntryPointV06 {
+		return &rpcPaymasterResult{PaymasterAndData: PackPaymasterAndDataV06(paymaster.Paymaster, paymaster.PaymasterData)}
+	}
+	return &rpcPaymasterResult{Paymaster: &paymaster.Paymaster, PaymasterData: paymaster.PaymasterData}
+}
@@ -0,0 +1,295 @@
+package aasdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestPaymasterServer(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	allowed := common.HexToAddress("0x01")
+	entrypointAddr := common.HexToAddress("0xee")
+	chainId := big.NewInt(1)
+	server, err := NewPaymasterServer(PaymasterServerConfig{
+		Paymaster:                     common.HexToAddress("0xbb"),
+		VerifyingSigner:               NewPrivateKeySigner(key),
+		Entrypoint:                    entrypointAddr,
+		ChainId:                       chainId,
+		Policies:                      []SponsorshipPolicy{AllowedSenders(allowed)},
+		PaymasterVerificationGasLimit: big.NewInt(0x10000),
+		PaymasterPostOpGasLimit:       big.NewInt(0x100),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create paymaster server: %v", err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client, err := NewPaymasterClient(PaymasterClientConfig{Url: httpServer.URL})
+	if err != nil {
+		t.Fatalf("Failed to create paymaster client: %v", err)
+	}
+
+	userOp := NewUserOpWithDefault(allowed, []byte{0x0a}, nil)
+	userOp.Nonce = big.NewInt(0)
+	req := &PaymasterRequest{UserOp: userOp, Entrypoint: entrypointAddr, ChainId: chainId}
+	stub, err := client.GetPaymasterStubData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Failed to get paymaster stub data: %v", err)
+	}
+	if stub.Paymaster != common.HexToAddress("0xbb") || stub.PaymasterVerificationGasLimit.Int64() != 0x10000 {
+		t.Errorf("Unexpected stub data %+v", stub)
+	}
+
+	userOp.Paymaster = stub.Paymaster
+	userOp.PaymasterVerificationGasLimit = stub.PaymasterVerificationGasLimit
+	userOp.PaymasterPostOpGasLimit = stub.PaymasterPostOpGasLimit
+	data, err := client.GetPaymasterData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Failed to get paymaster data: %v", err)
+	}
+	if data.Paymaster != stub.Paymaster || len(data.PaymasterData) != 64+crypto.SignatureLength {
+		t.Errorf("Unexpected paymaster data %+v", data)
+	}
+	userOp.PaymasterData = data.PaymasterData
+	packed := PackUserOperation(userOp)
+	signer, _, _, err := RecoverPaymasterSigner(&packed, chainId)
+	if err != nil {
+		t.Fatalf("Failed to recover paymaster signer: %v", err)
+	}
+	if signer != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("Expected paymaster data signed by %s, got %s", crypto.PubkeyToAddress(key.PublicKey).Hex(), signer.Hex())
+	}
+
+	// The senders not allowed are rejected by the paymaster
+	userOp.Sender = common.HexToAddress("0x02")
+	if _, err := client.GetPaymasterStubData(context.Background(), req); !errors.Is(err, ErrRejectedByPaymaster) {
+		t.Errorf("Expected ErrRejectedByPaymaster, got %v", err)
+	}
+	// The other chains are invalid
+	req.ChainId = big.NewInt(2)
+	if _, err := client.GetPaymasterStubData(context.Background(), req); !errors.Is(err, ErrInvalidUserOpFields) {
+		t.Errorf("Expected ErrInvalidUserOpFields, got %v", err)
+	}
+}
+
+func TestPaymasterServerV06(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	paymasterAddr := common.HexToAddress("0xbb")
+	entrypointAddr := common.HexToAddress("0xee")
+	chainId := big.NewInt(1)
+	server, err := NewPaymasterServer(PaymasterServerConfig{
+		Paymaster:                     paymasterAddr,
+		VerifyingSigner:               NewPrivateKeySigner(key),
+		Entrypoint:                    entrypointAddr,
+		EntrypointVersion:             EntryPointV06,
+		ChainId:                       chainId,
+		Caller:                        &senderNonceCaller{nonces: map[common.Address]int64{common.HexToAddress("0x01"): 3}},
+		SponsorAll:                    true,
+		PaymasterVerificationGasLimit: big.NewInt(0x10000),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create paymaster server: %v", err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client, err := NewPaymasterClient(PaymasterClientConfig{Url: httpServer.URL})
+	if err != nil {
+		t.Fatalf("Failed to create paymaster client: %v", err)
+	}
+
+	userOp := NewUserOpWithDefault(common.HexToAddress("0x01"), []byte{0x0a}, nil)
+	userOp.Nonce = big.NewInt(0)
+	req := &PaymasterRequest{UserOp: userOp, Entrypoint: entrypointAddr, EntrypointVersion: EntryPointV06, ChainId: chainId}
+
+	// The V0.6.0 stub has no paymaster gas limits
+	stub, err := client.GetPaymasterStubData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Failed to get paymaster stub data: %v", err)
+	}
+	if stub.Paymaster != paymasterAddr || stub.PaymasterVerificationGasLimit != nil {
+		t.Errorf("Unexpected stub data %+v", stub)
+	}
+
+	// The paymaster data is returned as paymasterAndData, signed over the V0.6.0 hash with the sender nonce
+	data, err := client.GetPaymasterData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Failed to get paymaster data: %v", err)
+	}
+	if data.Paymaster != paymasterAddr {
+		t.Fatalf("Expected paymaster %s, got %s", paymasterAddr.Hex(), data.Paymaster.Hex())
+	}
+	validity, sig, err := DecodePaymasterData(data.PaymasterData)
+	if err != nil {
+		t.Fatalf("Failed to decode paymaster data: %v", err)
+	}
+	packed := PackUserOperationV06(userOp)
+	validUntil, validAfter := validity.Ints()
+	hash, err := GetPaymasterHashV06(&packed, paymasterAddr, chainId, big.NewInt(3), validUntil, validAfter)
+	if err != nil {
+		t.Fatalf("Failed to get paymaster hash: %v", err)
+	}
+	sig = common.CopyBytes(sig)
+	sig[crypto.RecoveryIDOffset] -= 27
+	pub, err := crypto.SigToPub(accounts.TextHash(hash.Bytes()), sig)
+	if err != nil {
+		t.Fatalf("Failed to recover paymaster signer: %v", err)
+	}
+	if signer := crypto.PubkeyToAddress(*pub); signer != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("Expected paymaster data signed by %s, got %s", crypto.PubkeyToAddress(key.PublicKey).Hex(), signer.Hex())
+	}
+}
+
+// failingSigner fails with an error revealing its endpoint.
+type failingSigner struct {
+	Signer
+}
+
+func (s failingSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	return nil, errors.New("error calling https://signer.internal:8550: connection refused")
+}
+
+func TestPaymasterServerErrors(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	config := PaymasterServerConfig{
+		Paymaster:       common.HexToAddress("0xbb"),
+		VerifyingSigner: failingSigner{NewPrivateKeySigner(key)},
+		Entrypoint:      common.HexToAddress("0xee"),
+		ChainId:         big.NewInt(1),
+	}
+
+	// Sponsoring every user operation must be chosen explicitly
+	if _, err := NewPaymasterServer(config); err == nil {
+		t.Fatalf("Expected an error without policies")
+	}
+	config.SponsorAll = true
+	var logs bytes.Buffer
+	config.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	server, err := NewPaymasterServer(config)
+	if err != nil {
+		t.Fatalf("Failed to create paymaster server: %v", err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client, err := NewPaymasterClient(PaymasterClientConfig{Url: httpServer.URL})
+	if err != nil {
+		t.Fatalf("Failed to create paymaster client: %v", err)
+	}
+
+	// The signer error is logged, the client only gets a generic error
+	userOp := NewUserOpWithDefault(common.HexToAddress("0x01"), []byte{0x0a}, nil)
+	userOp.Nonce = big.NewInt(0)
+	userOp.Paymaster = config.Paymaster
+	_, err = client.GetPaymasterData(context.Background(), &PaymasterRequest{UserOp: userOp, Entrypoint: config.Entrypoint, ChainId: config.ChainId})
+	var bundlerErr *BundlerError
+	if !errors.As(err, &bundlerErr) || bundlerErr.Code != codeInternalError {
+		t.Fatalf("Expected an internal error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "signer.internal") {
+		t.Errorf("Expected the signer endpoint not to be returned, got %v", err)
+	}
+	if !strings.Contains(logs.String(), "signer.internal") {
+		t.Errorf("Expected the signer error to be logged, got %q", logs.String())
+	}
+}
+
+func TestSpendCap(t *testing.T) {
+	spendCap := NewSpendCap(big.NewInt(1000), time.Hour)
+	newRequest := func(nonce int64) *PaymasterRequest {
+		userOp := &UserOperation{
+			Sender:               common.HexToAddress("0x01"),
+			Nonce:                big.NewInt(nonce),
+			CallGasLimit:         big.NewInt(100),
+			VerificationGasLimit: big.NewInt(100),
+			PreVerificationGas:   big.NewInt(100),
+			MaxFeePerGas:         big.NewInt(1),
+		}
+		return &PaymasterRequest{UserOp: userOp, EntrypointVersion: EntryPointV07}
+	}
+	sender := common.HexToAddress("0x01")
+	for i := int64(0); i < 3; i++ {
+		if err := spendCap.Check(context.Background(), newRequest(i)); err != nil {
+			t.Fatalf("Unexpected rejection %d: %v", i, err)
+		}
+		if _, err := spendCap.Reserve(context.Background(), newRequest(i)); err != nil {
+			t.Fatalf("Unexpected reservation rejection %d: %v", i, err)
+		}
+	}
+	if spent := spendCap.Spent(sender); spent.Int64() != 900 {
+		t.Errorf("Expected 900 spent, got %s", spent)
+	}
+	if err := spendCap.Check(context.Background(), newRequest(3)); !errors.Is(err, ErrSponsorshipRejected) {
+		t.Errorf("Expected ErrSponsorshipRejected, got %v", err)
+	}
+
+	// Signing the same operation again doesn't count twice
+	if _, err := spendCap.Reserve(context.Background(), newRequest(2)); err != nil {
+		t.Fatalf("Unexpected rejection of the same operation: %v", err)
+	}
+	if spent := spendCap.Spent(sender); spent.Int64() != 900 {
+		t.Errorf("Expected 900 spent after signing again, got %s", spent)
+	}
+
+	// A released reservation doesn't count
+	release, err := spendCap.Reserve(context.Background(), newRequest(2))
+	if err != nil {
+		t.Fatalf("Unexpected rejection of the same operation: %v", err)
+	}
+	release()
+	if spent := spendCap.Spent(sender); spent.Int64() != 900 {
+		t.Errorf("Expected the previous reservation to be kept on release, got %s", spent)
+	}
+}
+
+func TestSpendCapConcurrent(t *testing.T) {
+	spendCap := NewSpendCap(big.NewInt(1000), 0)
+	var (
+		wg       sync.WaitGroup
+		accepted atomic.Int32
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(nonce int64) {
+			defer wg.Done()
+			userOp := &UserOperation{
+				Sender:               common.HexToAddress("0x01"),
+				Nonce:                big.NewInt(nonce),
+				CallGasLimit:         big.NewInt(100),
+				VerificationGasLimit: big.NewInt(100),
+				PreVerificationGas:   big.NewInt(100),
+				MaxFeePerGas:         big.NewInt(1),
+			}
+			if _, err := spendCap.Reserve(context.Background(), &PaymasterRequest{UserOp: userOp, EntrypointVersion: EntryPointV07}); err == nil {
+				accepted.Add(1)
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+	if n := accepted.Load(); n != 3 {
+		t.Errorf("Expected 3 sponsored operations, got %d", n)
+	}
+	if spent := spendCap.Spent(common.HexToAddress("0x01")); spent.Int64() != 900 {
+		t.Errorf("Expected 900 spent, got %s", spent)
+	}
+}
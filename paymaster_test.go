@@ -0,0 +1,36 @@
+package aasdk
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestDecodePaymasterData(t *testing.T) {
+	validity := NewPaymasterValidity(10 * time.Minute)
+	if !validity.ValidAfter.IsZero() {
+		t.Errorf("Expected the window to be valid immediately, got %+v", validity)
+	}
+	validUntil, validAfter := validity.Ints()
+	data, err := EncodePaymasterData(validUntil, validAfter, DummySignature)
+	if err != nil {
+		t.Fatalf("Failed to encode paymaster data: %v", err)
+	}
+	decoded, signature, err := DecodePaymasterData(data)
+	if err != nil {
+		t.Fatalf("Failed to decode paymaster data: %v", err)
+	}
+	if !decoded.ValidUntil.Equal(validity.ValidUntil) || !decoded.ValidAfter.Equal(validity.ValidAfter) {
+		t.Errorf("Unexpected validity %+v, expected %+v", decoded, validity)
+	}
+	if !bytes.Equal(signature, DummySignature) {
+		t.Errorf("Unexpected signature %x", signature)
+	}
+	if !decoded.Active(time.Now()) || !decoded.Expired(time.Now().Add(time.Hour)) {
+		t.Errorf("Unexpected validity status %+v", decoded)
+	}
+
+	if _, _, err := DecodePaymasterData(data[:63]); err == nil {
+		t.Errorf("Expected an error for short paymaster data")
+	}
+}
@@ -0,0 +1,133 @@
+package aasdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypoint"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/paymaster"
+)
+
+var (
+	// ErrInvalidPaymasterSignature is returned when the paymaster signature isn't from the verifying signer.
+	ErrInvalidPaymasterSignature = errors.New("invalid paymaster signature")
+)
+
+// PaymasterAndData is the parsed paymasterAndData of a VerifyingPaymaster user operation.
+type PaymasterAndData struct {
+	Paymaster            common.Address
+	VerificationGasLimit *big.Int
+	PostOpGasLimit       *big.Int
+	Validity             PaymasterValidity
+	Signature            []byte
+}
+
+// ParsePaymasterAndData parses the V0.7.0 paymasterAndData of a VerifyingPaymaster,
+// like its parsePaymasterAndData. The signature must be 65 bytes: the paymaster recovers it with
+// OpenZeppelin ECDSA.recover, which reverts on EIP-2098 compact signatures.
+func ParsePaymasterAndData(paymasterAndData []byte) (*PaymasterAndData, error) {
+	paymasterAddr, verGasLimit, postOpGasLimit, data, err := UnpackPaymasterAndData(paymasterAndData)
+	if err != nil {
+		return nil, err
+	}
+	validity, signature, err := DecodePaymasterData(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(signature) != crypto.SignatureLength {
+		return nil, fmt.Errorf("invalid paymaster signature length: %d, expected %d", len(signature), crypto.SignatureLength)
+	}
+	return &PaymasterAndData{
+		Paymaster:            paymasterAddr,
+		VerificationGasLimit: verGasLimit,
+		PostOpGasLimit:       postOpGasLimit,
+		Validity:             validity,
+		Signature:            signature,
+	}, nil
+}
+
+// RecoverPaymasterSigner recovers the signer of the VerifyingPaymaster signature of the packed user operation.
+// It returns the parsed paymasterAndData and the hash signed with EIP-191.
+func RecoverPaymasterSigner(packed *entrypoint.PackedUserOperation, chainId *big.Int) (common.Address, *PaymasterAndData, common.Hash, error) {
+	parsed, err := ParsePaymasterAndData(packed.PaymasterAndData)
+	if err != nil {
+		return common.Address{}, nil, common.Hash{}, err
+	}
+	validUntil, validAfter := parsed.Validity.Ints()
+	hash, err := GetPaymasterHash(packed, chainId, validUntil, validAfter)
+	if err != nil {
+		return common.Address{}, nil, common.Hash{}, err
+	}
+	sig := common.CopyBytes(parsed.Signature)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash(hash.Bytes()), sig)
+	if err != nil {
+		return common.Address{}, nil, common.Hash{}, fmt.Errorf("error recovering paymaster signer: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pub), parsed, hash, nil
+}
+
+// PaymasterVerification is the result of VerifyPaymasterData.
+type PaymasterVerification struct {
+	Signer          common.Address // the recovered signer
+	VerifyingSigner common.Address // the verifyingSigner of the paymaster
+	Hash            common.Hash
+	PaymasterAndData
+}
+
+// VerifyPaymasterData checks that the paymaster signature of the user operation is from the on-chain
+// verifyingSigner() of its paymaster, returning an error wrapping ErrInvalidPaymasterSignature otherwise.
+// With checkHash, the hash computed locally is also cross-checked against the paymaster getHash.
+// It only supports the V0.7.0 VerifyingPaymaster.
+func (c *Client) VerifyPaymasterData(ctx context.Context, userOp *UserOperation, checkHash bool) (*PaymasterVerification, error) {
+	if c.version == EntryPointV06 {
+		return nil, fmt.Errorf("verifying paymaster data is not supported for entrypoint %s", c.version)
+	}
+	if userOp.Paymaster == (common.Address{}) {
+		return nil, fmt.Errorf("no paymaster")
+	}
+	packed := PackUserOperation(userOp)
+	signer, parsed, hash, err := RecoverPaymasterSigner(&packed, c.chainId)
+	if err != nil {
+		return nil, err
+	}
+
+	caller, err := paymaster.NewVerifyingPaymasterCaller(userOp.Paymaster, c.eth)
+	if err != nil {
+		return nil, fmt.Errorf("error creating paymaster client: %v", err)
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	verifyingSigner, err := caller.VerifyingSigner(opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting verifying signer: %v", err)
+	}
+	if checkHash {
+		validUntil, validAfter := parsed.Validity.Ints()
+		onchain, err := caller.GetHash(opts, paymaster.PackedUserOperation(packed), validUntil, validAfter)
+		if err != nil {
+			return nil, fmt.Errorf("error getting paymaster hash: %v", err)
+		}
+		if common.Hash(onchain) != hash {
+			return nil, fmt.Errorf("paymaster hash mismatch: computed %s, paymaster returned %s", hash.Hex(), common.Hash(onchain).Hex())
+		}
+	}
+
+	verification := &PaymasterVerification{
+		Signer:           signer,
+		VerifyingSigner:  verifyingSigner,
+		Hash:             hash,
+		PaymasterAndData: *parsed,
+	}
+	if signer != verifyingSigner {
+		return verification, fmt.Errorf("%w: signed by %s, verifying signer is %s", ErrInvalidPaymasterSignature, signer.Hex(), verifyingSigner.Hex())
+	}
+	return verification, nil
+}
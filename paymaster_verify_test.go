@@ -0,0 +1,191 @@
+package aasdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/paymaster"
+)
+
+func TestRecoverPaymasterSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer := NewPrivateKeySigner(key)
+	chainId := big.NewInt(1)
+	userOp := NewUserOpWithDefault(common.HexToAddress("0x01"), []byte{0x0a}, nil)
+	userOp.Nonce = big.NewInt(0)
+	userOp.Paymaster = common.HexToAddress("0xbb")
+
+	provider := NewVerifyingPaymaster(userOp.Paymaster, signer, 0)
+	result, err := provider.GetPaymasterData(context.Background(), &PaymasterRequest{UserOp: userOp, ChainId: chainId})
+	if err != nil {
+		t.Fatalf("Failed to get paymaster data: %v", err)
+	}
+	userOp.PaymasterData = result.PaymasterData
+
+	packed := PackUserOperation(userOp)
+	recovered, parsed, _, err := RecoverPaymasterSigner(&packed, chainId)
+	if err != nil {
+		t.Fatalf("Failed to recover paymaster signer: %v", err)
+	}
+	if recovered != signer.Address() {
+		t.Errorf("Expected signer %s, got %s", signer.Address().Hex(), recovered.Hex())
+	}
+	if parsed.Paymaster != userOp.Paymaster || parsed.VerificationGasLimit.Cmp(userOp.PaymasterVerificationGasLimit) != 0 || parsed.Validity.ValidUntil.IsZero() {
+		t.Errorf("Unexpected paymasterAndData %+v", parsed)
+	}
+
+	// Any change to the user operation invalidates the signature
+	userOp.CallData = []byte{0x0b}
+	packed = PackUserOperation(userOp)
+	if recovered, _, _, err := RecoverPaymasterSigner(&packed, chainId); err == nil && recovered == signer.Address() {
+		t.Errorf("Expected another signer for a modified user operation")
+	}
+}
+
+// signedPaymasterUserOp returns a user operation signed by the VerifyingPaymaster signer.
+func signedPaymasterUserOp(t *testing.T, signer Signer, chainId *big.Int) *UserOperation {
+	userOp := NewUserOpWithDefault(common.HexToAddress("0x01"), []byte{0x0a}, nil)
+	userOp.Nonce = big.NewInt(0)
+	userOp.Paymaster = common.HexToAddress("0xbb")
+	provider := NewVerifyingPaymaster(userOp.Paymaster, signer, 0)
+	result, err := provider.GetPaymasterData(context.Background(), &PaymasterRequest{UserOp: userOp, ChainId: chainId})
+	if err != nil {
+		t.Fatalf("Failed to get paymaster data: %v", err)
+	}
+	userOp.PaymasterData = result.PaymasterData
+	return userOp
+}
+
+func TestRecoverPaymasterSignerCompact(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer := NewPrivateKeySigner(key)
+	chainId := big.NewInt(1)
+	userOp := signedPaymasterUserOp(t, signer, chainId)
+
+	// The EIP-2098 compact signature, with yParity in the top bit of S, reverts in the paymaster
+	sig := userOp.PaymasterData[paymasterValidityLength:]
+	compact := common.CopyBytes(sig[:64])
+	compact[32] |= (sig[crypto.RecoveryIDOffset] - 27) << 7
+	userOp.PaymasterData = append(common.CopyBytes(userOp.PaymasterData[:paymasterValidityLength]), compact...)
+
+	packed := PackUserOperation(userOp)
+	if _, err := ParsePaymasterAndData(packed.PaymasterAndData); err == nil {
+		t.Errorf("Expected an error for a 64 bytes compact signature")
+	}
+	if _, _, _, err := RecoverPaymasterSigner(&packed, chainId); err == nil {
+		t.Errorf("Expected the compact signature not to be recovered")
+	}
+
+	userOp.PaymasterData = userOp.PaymasterData[:len(userOp.PaymasterData)-1]
+	packed = PackUserOperation(userOp)
+	if _, _, _, err := RecoverPaymasterSigner(&packed, chainId); err == nil {
+		t.Errorf("Expected an error for a 63 bytes signature")
+	}
+}
+
+// newPaymasterNode starts a node answering the VerifyingPaymaster verifyingSigner and getHash calls.
+func newPaymasterNode(t *testing.T, verifyingSigner common.Address, hash *common.Hash) (*Client, func()) {
+	parsed, err := paymaster.VerifyingPaymasterMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to parse paymaster ABI: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Id     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Method != "eth_call" {
+			t.Errorf("Unexpected request %s, error %v", request.Method, err)
+			return
+		}
+		var call struct {
+			Input hexutil.Bytes `json:"input"`
+			Data  hexutil.Bytes `json:"data"`
+		}
+		if err := json.Unmarshal(request.Params[0], &call); err != nil {
+			t.Errorf("Failed to decode call: %v", err)
+		}
+		input := call.Input
+		if len(input) == 0 {
+			input = call.Data
+		}
+		var result []byte
+		switch {
+		case bytes.HasPrefix(input, parsed.Methods["verifyingSigner"].ID):
+			result = common.LeftPadBytes(verifyingSigner.Bytes(), 32)
+		case bytes.HasPrefix(input, parsed.Methods["getHash"].ID):
+			result = hash.Bytes()
+		default:
+			t.Errorf("Unexpected call %x", input)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": request.Id, "result": hexutil.Bytes(result)})
+	}))
+	eth, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create eth client: %v", err)
+	}
+	return &Client{chainId: big.NewInt(1), config: &Config{}, eth: eth}, server.Close
+}
+
+func TestVerifyPaymasterData(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer := NewPrivateKeySigner(key)
+	userOp := signedPaymasterUserOp(t, signer, big.NewInt(1))
+	packed := PackUserOperation(userOp)
+	_, _, hash, err := RecoverPaymasterSigner(&packed, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("Failed to recover paymaster signer: %v", err)
+	}
+
+	client, stop := newPaymasterNode(t, signer.Address(), &hash)
+	defer stop()
+	verification, err := client.VerifyPaymasterData(context.Background(), userOp, true)
+	if err != nil {
+		t.Fatalf("Failed to verify paymaster data: %v", err)
+	}
+	if verification.Signer != signer.Address() || verification.VerifyingSigner != signer.Address() || verification.Hash != hash {
+		t.Errorf("Unexpected verification %+v", verification)
+	}
+
+	// The paymaster getHash must match the local hash
+	onchain := common.Hash{0x01}
+	mismatch, stop := newPaymasterNode(t, signer.Address(), &onchain)
+	defer stop()
+	if _, err := mismatch.VerifyPaymasterData(context.Background(), userOp, true); err == nil {
+		t.Errorf("Expected an error for a paymaster hash mismatch")
+	}
+	if _, err := mismatch.VerifyPaymasterData(context.Background(), userOp, false); err != nil {
+		t.Errorf("Expected the hash not to be checked, got %v", err)
+	}
+
+	// The signature must be from the verifying signer
+	other, stop := newPaymasterNode(t, common.HexToAddress("0xcc"), &hash)
+	defer stop()
+	verification, err = other.VerifyPaymasterData(context.Background(), userOp, true)
+	if !errors.Is(err, ErrInvalidPaymasterSignature) {
+		t.Errorf("Expected ErrInvalidPaymasterSignature, got %v", err)
+	}
+	if verification == nil || verification.Signer != signer.Address() || verification.VerifyingSigner != common.HexToAddress("0xcc") {
+		t.Errorf("Unexpected verification %+v", verification)
+	}
+}
@@ -0,0 +1,256 @@
+package aasdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// UserOpReceipt is the result of eth_getUserOperationReceipt.
+type UserOpReceipt struct {
+	UserOpHash    common.Hash
+	EntryPoint    common.Address
+	Sender        common.Address
+	Paymaster     common.Address
+	Nonce         *big.Int
+	Success       bool
+	Reason        []byte // the revert data of the execution, if reverted
+	ActualGasCost *big.Int
+	ActualGasUsed *big.Int
+	From          common.Address
+	Receipt       *TxReceipt   // the receipt of the bundle transaction
+	Logs          []*types.Log // the logs emitted by the user operation
+	ReturnData    []byte
+}
+
+// TxReceipt is the receipt of the transaction including the user operation.
+type TxReceipt struct {
+	BlockHash         common.Hash
+	BlockNumber       *big.Int
+	From              common.Address
+	To                *common.Address
+	CumulativeGasUsed uint64
+	GasUsed           uint64
+	Logs              []*types.Log
+	LogsBloom         types.Bloom
+	TransactionHash   common.Hash
+	TransactionIndex  uint
+	EffectiveGasPrice *big.Int
+	Status            uint64
+}
+
+// DecodedLog is a log decoded with a contract ABI.
+type DecodedLog struct {
+	Name string         // the event name
+	Args map[string]any // the indexed and non-indexed event arguments
+	Log  *types.Log
+}
+
+// rpcUserOpReceipt is the RPC format of UserOpReceipt, with hex encoded quantities.
+type rpcUserOpReceipt struct {
+	UserOpHash    common.Hash    `json:"userOpHash"`
+	EntryPoint    common.Address `json:"entryPoint"`
+	Sender        common.Address `json:"sender"`
+	Paymaster     common.Address `json:"paymaster"`
+	Nonce         *hexutil.Big   `json:"nonce"`
+	Success       bool           `json:"success"`
+	Reason        hexutil.Bytes  `json:"reason"`
+	ActualGasCost *hexutil.Big   `json:"actualGasCost"`
+	ActualGasUsed *hexutil.Big   `json:"actualGasUsed"`
+	From          common.Address `json:"from"`
+	Receipt       *TxReceipt     `json:"receipt"`
+	Logs          []*types.Log   `json:"logs"`
+	ReturnData    hexutil.Bytes  `json:"returnData"`
+}
+
+// rpcTxReceipt is the RPC format of TxReceipt, with hex encoded quantities.
+type rpcTxReceipt struct {
+	BlockHash         common.Hash     `json:"blockHash"`
+	BlockNumber       *hexutil.Big    `json:"blockNumber"`
+	From              common.Address  `json:"from"`
+	To                *common.Address `json:"to"`
+	CumulativeGasUsed hexutil.Uint64  `json:"cumulativeGasUsed"`
+	GasUsed           hexutil.Uint64  `json:"gasUsed"`
+	Logs              []*types.Log    `json:"logs"`
+	LogsBloom         types.Bloom     `json:"logsBloom"`
+	TransactionHash   common.Hash     `json:"transactionHash"`
+	TransactionIndex  hexutil.Uint64  `json:"transactionIndex"`
+	EffectiveGasPrice *hexutil.Big    `json:"effectiveGasPrice"`
+	Status            *hexutil.Uint64 `json:"status"`
+}
+
+// MarshalJSON implements json.Marshaler, in the format of eth_getUserOperationReceipt.
+func (r UserOpReceipt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rpcUserOpReceipt{
+		UserOpHash:    r.UserOpHash,
+		EntryPoint:    r.EntryPoint,
+		Sender:        r.Sender,
+		Paymaster:     r.Paymaster,
+		Nonce:         (*hexutil.Big)(r.Nonce),
+		Success:       r.Success,
+		Reason:        r.Reason,
+		ActualGasCost: (*hexutil.Big)(r.ActualGasCost),
+		ActualGasUsed: (*hexutil.Big)(r.ActualGasUsed),
+		From:          r.From,
+		Receipt:       r.Receipt,
+		Logs:          r.Logs,
+		ReturnData:    r.ReturnData,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, parsing the hex encoded quantities.
+func (r *UserOpReceipt) UnmarshalJSON(b []byte) error {
+	var dec rpcUserOpReceipt
+	if err := json.Unmarshal(b, &dec); err != nil {
+		return err
+	}
+	*r = UserOpReceipt{
+		UserOpHash:    dec.UserOpHash,
+		EntryPoint:    dec.EntryPoint,
+		Sender:        dec.Sender,
+		Paymaster:     dec.Paymaster,
+		Nonce:         (*big.Int)(dec.Nonce),
+		Success:       dec.Success,
+		Reason:        dec.Reason,
+		ActualGasCost: (*big.Int)(dec.ActualGasCost),
+		ActualGasUsed: (*big.Int)(dec.ActualGasUsed),
+		From:          dec.From,
+		Receipt:       dec.Receipt,
+		Logs:          dec.Logs,
+		ReturnData:    dec.ReturnData,
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, in the format of eth_getTransactionReceipt.
+func (r TxReceipt) MarshalJSON() ([]byte, error) {
+	status := hexutil.Uint64(r.Status)
+	return json.Marshal(rpcTxReceipt{
+		BlockHash:         r.BlockHash,
+		BlockNumber:       (*hexutil.Big)(r.BlockNumber),
+		From:              r.From,
+		To:                r.To,
+		CumulativeGasUsed: hexutil.Uint64(r.CumulativeGasUsed),
+		GasUsed:           hexutil.Uint64(r.GasUsed),
+		Logs:              r.Logs,
+		LogsBloom:         r.LogsBloom,
+		TransactionHash:   r.TransactionHash,
+		TransactionIndex:  hexutil.Uint64(r.TransactionIndex),
+		EffectiveGasPrice: (*hexutil.Big)(r.EffectiveGasPrice),
+		Status:            &status,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, parsing the hex encoded quantities.
+func (r *TxReceipt) UnmarshalJSON(b []byte) error {
+	var dec rpcTxReceipt
+	if err := json.Unmarshal(b, &dec); err != nil {
+		return err
+	}
+	*r = TxReceipt{
+		BlockHash:         dec.BlockHash,
+		BlockNumber:       (*big.Int)(dec.BlockNumber),
+		From:              dec.From,
+		To:                dec.To,
+		CumulativeGasUsed: uint64(dec.CumulativeGasUsed),
+		GasUsed:           uint64(dec.GasUsed),
+		Logs:              dec.Logs,
+		LogsBloom:         dec.LogsBloom,
+		TransactionHash:   dec.TransactionHash,
+		TransactionIndex:  uint(dec.TransactionIndex),
+		EffectiveGasPrice: (*big.Int)(dec.EffectiveGasPrice),
+		Status:            types.ReceiptStatusSuccessful,
+	}
+	// Some bundlers omit the status of the bundle transaction, which was mined anyway.
+	if dec.Status != nil {
+		r.Status = uint64(*dec.Status)
+	}
+	return nil
+}
+
+// ToReceipt converts the receipt to a go-ethereum receipt.
+func (r *TxReceipt) ToReceipt() *types.Receipt {
+	return &types.Receipt{
+		Status:            r.Status,
+		CumulativeGasUsed: r.CumulativeGasUsed,
+		Bloom:             r.LogsBloom,
+		Logs:              r.Logs,
+		TxHash:            r.TransactionHash,
+		GasUsed:           r.GasUsed,
+		EffectiveGasPrice: r.EffectiveGasPrice,
+		BlockHash:         r.BlockHash,
+		BlockNumber:       r.BlockNumber,
+		TransactionIndex:  r.TransactionIndex,
+	}
+}
+
+// SenderLogs returns the logs of the user operation emitted by its sender.
+func (r *UserOpReceipt) SenderLogs() []*types.Log {
+	return r.FilterLogs(r.Sender)
+}
+
+// FilterLogs returns the logs of the user operation emitted by the address,
+// and matching the event topics if any.
+func (r *UserOpReceipt) FilterLogs(address common.Address, topics ...common.Hash) []*types.Log {
+	var result []*types.Log
+	for _, log := range r.Logs {
+		if log.Address != address {
+			continue
+		}
+		if len(topics) > 0 && (len(log.Topics) == 0 || !containsHash(topics, log.Topics[0])) {
+			continue
+		}
+		result = append(result, log)
+	}
+	return result
+}
+
+// DecodeLogs decodes the logs with the contract ABI, skipping the logs of events it doesn't declare.
+func DecodeLogs(contractABI *abi.ABI, logs []*types.Log) ([]DecodedLog, error) {
+	var result []DecodedLog
+	for _, log := range logs {
+		if len(log.Topics) == 0 {
+			continue
+		}
+		event, err := contractABI.EventByID(log.Topics[0])
+		if err != nil {
+			continue
+		}
+		args := make(map[string]any)
+		if len(log.Data) > 0 {
+			if err := contractABI.UnpackIntoMap(args, event.Name, log.Data); err != nil {
+				return nil, fmt.Errorf("error unpacking %s log: %v", event.Name, err)
+			}
+		}
+		var indexed abi.Arguments
+		for _, input := range event.Inputs {
+			if input.Indexed {
+				indexed = append(indexed, input)
+			}
+		}
+		if err := abi.ParseTopicsIntoMap(args, indexed, log.Topics[1:]); err != nil {
+			return nil, fmt.Errorf("error parsing %s topics: %v", event.Name, err)
+		}
+		result = append(result, DecodedLog{Name: event.Name, Args: args, Log: log})
+	}
+	return result, nil
+}
+
+// DecodeSenderLogs decodes the logs emitted by the sender with the account ABI.
+func (r *UserOpReceipt) DecodeSenderLogs(accountABI *abi.ABI) ([]DecodedLog, error) {
+	return DecodeLogs(accountABI, r.SenderLogs())
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package aasdk
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/account"
+)
+
+func TestUserOpReceiptUnmarshal(t *testing.T) {
+	data := `{
+		"userOpHash": "0x1111111111111111111111111111111111111111111111111111111111111111",
+		"sender": "0x2222222222222222222222222222222222222222",
+		"nonce": "0x5",
+		"success": true,
+		"actualGasCost": "0x2386f26fc10000",
+		"actualGasUsed": "0x1d4c0",
+		"receipt": {
+			"blockNumber": "0x10",
+			"gasUsed": "0x30d40",
+			"transactionIndex": "0x2",
+			"effectiveGasPrice": "0x3b9aca00",
+			"status": "0x1"
+		}
+	}`
+	var receipt UserOpReceipt
+	if err := json.Unmarshal([]byte(data), &receipt); err != nil {
+		t.Fatalf("Failed to unmarshal receipt: %v", err)
+	}
+	if receipt.Nonce.Int64() != 5 || receipt.ActualGasCost.Int64() != 10000000000000000 || receipt.ActualGasUsed.Int64() != 120000 {
+		t.Errorf("Unexpected nonce %s, gas cost %s or gas used %s", receipt.Nonce, receipt.ActualGasCost, receipt.ActualGasUsed)
+	}
+	if receipt.Receipt == nil {
+		t.Fatalf("Missing transaction receipt")
+	}
+	txReceipt := receipt.Receipt.ToReceipt()
+	if txReceipt.BlockNumber.Int64() != 16 || txReceipt.GasUsed != 200000 || txReceipt.TransactionIndex != 2 || txReceipt.Status != types.ReceiptStatusSuccessful {
+		t.Errorf("Unexpected receipt %+v", txReceipt)
+	}
+}
+
+func TestUserOpReceiptFilterLogs(t *testing.T) {
+	sender := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	topic := common.HexToHash("0x01")
+	receipt := UserOpReceipt{
+		Sender: sender,
+		Logs: []*types.Log{
+			{Address: sender, Topics: []common.Hash{topic}},
+			{Address: common.HexToAddress("0x3333333333333333333333333333333333333333"), Topics: []common.Hash{topic}},
+			{Address: sender, Topics: []common.Hash{common.HexToHash("0x02")}},
+		},
+	}
+	if logs := receipt.SenderLogs(); len(logs) != 2 {
+		t.Errorf("Expected 2 sender logs, got %d", len(logs))
+	}
+	if logs := receipt.FilterLogs(sender, topic); len(logs) != 1 || logs[0] != receipt.Logs[0] {
+		t.Errorf("Unexpected filtered logs %v", logs)
+	}
+}
+
+func TestUserOpReceiptRoundTrip(t *testing.T) {
+	to := common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+	log := &types.Log{
+		Address:     common.HexToAddress("0x2222222222222222222222222222222222222222"),
+		Topics:      []common.Hash{common.HexToHash("0x01")},
+		Data:        []byte{0x02},
+		BlockNumber: 16,
+		TxHash:      common.HexToHash("0x03"),
+	}
+	receipt := UserOpReceipt{
+		UserOpHash:    common.HexToHash("0x11"),
+		EntryPoint:    to,
+		Sender:        log.Address,
+		Paymaster:     common.HexToAddress("0xaa"),
+		Nonce:         big.NewInt(5),
+		Success:       false,
+		Reason:        []byte{0xde, 0xad},
+		ActualGasCost: big.NewInt(10000000000000000),
+		ActualGasUsed: big.NewInt(120000),
+		From:          common.HexToAddress("0xbb"),
+		Receipt: &TxReceipt{
+			BlockHash:         common.HexToHash("0x04"),
+			BlockNumber:       big.NewInt(16),
+			From:              common.HexToAddress("0xbb"),
+			To:                &to,
+			CumulativeGasUsed: 300000,
+			GasUsed:           200000,
+			Logs:              []*types.Log{log},
+			TransactionHash:   common.HexToHash("0x03"),
+			TransactionIndex:  2,
+			EffectiveGasPrice: big.NewInt(1000000000),
+			Status:            types.ReceiptStatusFailed,
+		},
+		Logs:       []*types.Log{log},
+		ReturnData: []byte{0x05},
+	}
+
+	encoded, err := json.Marshal(&receipt)
+	if err != nil {
+		t.Fatalf("Failed to marshal receipt: %v", err)
+	}
+	// The RPC field names and hex quantities are used
+	for _, field := range []string{`"userOpHash":`, `"nonce":"0x5"`, `"actualGasCost":"0x2386f26fc10000"`, `"blockNumber":"0x10"`, `"status":"0x0"`, `"reason":"0xdead"`} {
+		if !strings.Contains(string(encoded), field) {
+			t.Errorf("Expected %s in %s", field, encoded)
+		}
+	}
+
+	var decoded UserOpReceipt
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal receipt: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, receipt) {
+		t.Errorf("Expected round trip receipt %+v, got %+v", receipt, decoded)
+	}
+
+	// Values are encoded as well as pointers
+	byValue, err := json.Marshal(receipt)
+	if err != nil || string(byValue) != string(encoded) {
+		t.Errorf("Expected the same encoding by value, got %s, error %v", byValue, err)
+	}
+}
+
+// accountLogs returns the Initialized and SimpleAccountInitialized logs of the account, with a log of another contract.
+func accountLogs(t *testing.T, parsed *abi.ABI, sender common.Address, owner common.Address) []*types.Log {
+	version, err := parsed.Events["Initialized"].Inputs.Pack(uint64(1))
+	if err != nil {
+		t.Fatalf("Failed to pack Initialized: %v", err)
+	}
+	entrypointAddr := common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+	return []*types.Log{
+		{Address: sender, Topics: []common.Hash{parsed.Events["Initialized"].ID}, Data: version},
+		{Address: sender, Topics: []common.Hash{
+			parsed.Events["SimpleAccountInitialized"].ID,
+			common.BytesToHash(entrypointAddr.Bytes()),
+			common.BytesToHash(owner.Bytes()),
+		}},
+		// An event the ABI doesn't declare, and a log without topics
+		{Address: sender, Topics: []common.Hash{common.HexToHash("0x01")}},
+		{Address: sender},
+		// A log of another contract
+		{Address: common.HexToAddress("0x3333333333333333333333333333333333333333"), Topics: []common.Hash{parsed.Events["Initialized"].ID}, Data: version},
+	}
+}
+
+func TestDecodeLogs(t *testing.T) {
+	parsed, err := account.SimpleAccountMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to parse account ABI: %v", err)
+	}
+	sender := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	owner := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	logs := accountLogs(t, parsed, sender, owner)
+
+	decoded, err := DecodeLogs(parsed, logs)
+	if err != nil {
+		t.Fatalf("Failed to decode logs: %v", err)
+	}
+	if len(decoded) != 3 {
+		t.Fatalf("Expected 3 decoded logs, got %d", len(decoded))
+	}
+	if decoded[0].Name != "Initialized" || decoded[0].Args["version"] != uint64(1) || decoded[0].Log != logs[0] {
+		t.Errorf("Unexpected Initialized log %+v", decoded[0])
+	}
+	if decoded[1].Name != "SimpleAccountInitialized" || decoded[1].Args["owner"] != owner {
+		t.Errorf("Unexpected SimpleAccountInitialized log %+v", decoded[1])
+	}
+
+	// Malformed data of a declared event is an error
+	malformed := &types.Log{Address: sender, Topics: []common.Hash{parsed.Events["Initialized"].ID}, Data: []byte{0x01}}
+	if _, err := DecodeLogs(parsed, []*types.Log{malformed}); err == nil {
+		t.Errorf("Expected an error for malformed data")
+	}
+}
+
+func TestDecodeSenderLogs(t *testing.T) {
+	parsed, err := account.SimpleAccountMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to parse account ABI: %v", err)
+	}
+	sender := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	owner := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	receipt := UserOpReceipt{Sender: sender, Logs: accountLogs(t, parsed, sender, owner)}
+
+	// The log of the other contract isn't decoded
+	decoded, err := receipt.DecodeSenderLogs(parsed)
+	if err != nil {
+		t.Fatalf("Failed to decode sender logs: %v", err)
+	}
+	if len(decoded) != 2 || decoded[0].Name != "Initialized" || decoded[1].Name != "SimpleAccountInitialized" {
+		t.Errorf("Unexpected sender logs %+v", decoded)
+	}
+	for _, log := range decoded {
+		if log.Log.Address != sender {
+			t.Errorf("Expected only sender logs, got %s", log.Log.Address.Hex())
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package aasdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+const (
+	DefaultRemoteSignMethod          = "account_signData"
+	DefaultRemoteSignTypedDataMethod = "account_signTypedData"
+	DefaultRemoteSignTxMethod        = "eth_signTransaction"
+
+	// The Clef content type for EIP-191 personal messages.
+	textPlainContentType = "text/plain"
+)
+
+var (
+	// ErrHashSigningUnsupported is returned when the remote signer has no method to sign raw hashes.
+	ErrHashSigningUnsupported = errors.New("remote signer does not support signing raw hashes")
+)
+
+type RemoteSignerConfig struct {
+	// The url of the signing service.
+	Url string
+	// The address of the key held by the signing service.
+	Address common.Address
+	// The method to sign EIP-191 messages, defaults to account_signData.
+	// Methods other than account_signData are called with [address, data], e.g. eth_sign.
+	SignMethod string
+	// The method to sign EIP-712 typed data, defaults to account_signTypedData.
+	SignTypedDataMethod string
+	// The method to sign raw hashes, called with [address, hash].
+	// It's optional, SignHash returns ErrHashSigningUnsupported when empty.
+	SignHashMethod string
+	// The method to sign transactions, called with [transaction], defaults to eth_signTransaction.
+	// It's used for the transactions sent by the client, e.g. Prefund, as they can't be signed without raw hash signing.
+	// The signed transaction is returned as raw bytes, or in the raw field of an object as by account_signTransaction.
+	SignTxMethod string
+	// The headers added to every request, e.g. authorization.
+	Headers map[string]string
+	// The http client, defaults to a client with DefaultRequestTimeout.
+	HttpClient *http.Client
+}
+
+// RemoteSigner forwards signing requests to a remote JSON-RPC signing service,
+// such as Clef or Web3Signer, so the key never has to be loaded into the process.
+type RemoteSigner struct {
+	config    RemoteSignerConfig
+	transport *transport
+}
+
+var (
+	_ Signer            = (*RemoteSigner)(nil)
+	_ MessageSigner     = (*RemoteSigner)(nil)
+	_ TransactionSigner = (*RemoteSigner)(nil)
+)
+
+// NewRemoteSigner creates a new RemoteSigner with given config.
+func NewRemoteSigner(config RemoteSignerConfig) (*RemoteSigner, error) {
+	if config.Url == "" {
+		return nil, fmt.Errorf("remote signer url is empty")
+	}
+	if config.Address == (common.Address{}) {
+		return nil, fmt.Errorf("remote signer address is empty")
+	}
+	if config.SignMethod == "" {
+		config.SignMethod = DefaultRemoteSignMethod
+	}
+	if config.SignTypedDataMethod == "" {
+		config.SignTypedDataMethod = DefaultRemoteSignTypedDataMethod
+	}
+	if config.SignTxMethod == "" {
+		config.SignTxMethod = DefaultRemoteSignTxMethod
+	}
+	return &RemoteSigner{
+		config:    config,
+		transport: newTransport(config.Url, config.Headers, config.HttpClient),
+	}, nil
+}
+
+// Address implements Signer.
+func (s *RemoteSigner) Address() common.Address {
+	return s.config.Address
+}
+
+// SignHash implements Signer.
+func (s *RemoteSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	if s.config.SignHashMethod == "" {
+		return nil, ErrHashSigningUnsupported
+	}
+	return s.sign(ctx, s.config.SignHashMethod, []any{s.config.Address, hexutil.Bytes(hash)})
+}
+
+// SignMessage implements MessageSigner.
+func (s *RemoteSigner) SignMessage(ctx context.Context, message []byte) ([]byte, error) {
+	params := []any{s.config.Address, hexutil.Bytes(message)}
+	if s.config.SignMethod == DefaultRemoteSignMethod {
+		params = append([]any{textPlainContentType}, params...)
+	}
+	return s.sign(ctx, s.config.SignMethod, params)
+}
+
+// SignTypedData implements Signer.
+func (s *RemoteSigner) SignTypedData(ctx context.Context, typedData apitypes.TypedData) ([]byte, error) {
+	return s.sign(ctx, s.config.SignTypedDataMethod, []any{s.config.Address, typedData})
+}
+
+// SignTransaction implements TransactionSigner.
+// It checks that the remote signer signed the transaction as is, from the configured address.
+func (s *RemoteSigner) SignTransaction(ctx context.Context, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	result, err := s.call(ctx, s.config.SignTxMethod, []any{newSendTxArgs(s.config.Address, tx, chainId)})
+	if err != nil {
+		return nil, err
+	}
+	var raw hexutil.Bytes
+	if err := json.Unmarshal(result, &raw); err != nil {
+		var response struct {
+			Raw hexutil.Bytes `json:"raw"`
+		}
+		if err := json.Unmarshal(result, &response); err != nil {
+			return nil, fmt.Errorf("error unmarshalling signed transaction: %v", err)
+		}
+		raw = response.Raw
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("error decoding signed transaction: %v", err)
+	}
+	txSigner := types.LatestSignerForChainID(chainId)
+	if txSigner.Hash(signed) != txSigner.Hash(tx) {
+		return nil, fmt.Errorf("remote signer signed another transaction: %s", signed.Hash().Hex())
+	}
+	sender, err := types.Sender(txSigner, signed)
+	if err != nil {
+		return nil, fmt.Errorf("error recovering transaction sender: %v", err)
+	}
+	if sender != s.config.Address {
+		return nil, fmt.Errorf("transaction signed by %s, expected %s", sender.Hex(), s.config.Address.Hex())
+	}
+	return signed, nil
+}
+
+// newSendTxArgs returns the transaction in the format of the signing services.
+func newSendTxArgs(from common.Address, tx *types.Transaction, chainId *big.Int) apitypes.SendTxArgs {
+	data := hexutil.Bytes(tx.Data())
+	args := apitypes.SendTxArgs{
+		From:    common.NewMixedcaseAddress(from),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Input:   &data,
+		ChainID: (*hexutil.Big)(chainId),
+	}
+	if to := tx.To(); to != nil {
+		mixed := common.NewMixedcaseAddress(*to)
+		args.To = &mixed
+	}
+	if tx.Type() == types.LegacyTxType {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	} else {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	}
+	return args
+}
+
+// sign calls the signing method and normalizes the returned signature.
+func (s *RemoteSigner) sign(ctx context.Context, method string, params []any) ([]byte, error) {
+	result, err := s.call(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+	var signature hexutil.Bytes
+	if err = json.Unmarshal(result, &signature); err != nil {
+		return nil, fmt.Errorf("error unmarshalling signature: %v", err)
+	}
+	if len(signature) != crypto.SignatureLength {
+		return nil, fmt.Errorf("invalid signature length: %d", len(signature))
+	}
+	if signature[crypto.RecoveryIDOffset] < 27 {
+		signature[crypto.RecoveryIDOffset] += 27
+	}
+	return signature, nil
+}
+
+// call calls the signing method and returns its raw result.
+func (s *RemoteSigner) call(ctx context.Context, method string, params []any) (json.RawMessage, error) {
+	bytes, err := s.transport.call(ctx, method, params)
+	if err != nil {
+		return nil, fmt.Errorf("error calling %s: %w", method, err)
+	}
+	var response jsonRpcResponse[json.RawMessage]
+	if err = json.Unmarshal(bytes, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %v", err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("error from remote signer: %w", response.Error.toError())
+	}
+	return response.Result, nil
+}
@@ -0,0 +1,210 @@
+package aasdk
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypoint"
+)
+
+// newStandInSigner starts a local signing service implementing account_signData for text/plain data
+// and eth_signTransaction.
+func newStandInSigner(t *testing.T, key *ecdsa.PrivateKey, token string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var request struct {
+			Id     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+			return
+		}
+		var result hexutil.Bytes
+		switch {
+		case request.Method == DefaultRemoteSignMethod && len(request.Params) == 3:
+			var data hexutil.Bytes
+			if err := json.Unmarshal(request.Params[2], &data); err != nil {
+				t.Errorf("Failed to decode data: %v", err)
+				return
+			}
+			signature, err := crypto.Sign(accounts.TextHash(data), key)
+			if err != nil {
+				t.Errorf("Failed to sign data: %v", err)
+				return
+			}
+			signature[crypto.RecoveryIDOffset] += 27
+			result = signature
+		case request.Method == DefaultRemoteSignTxMethod && len(request.Params) == 1:
+			var args apitypes.SendTxArgs
+			if err := json.Unmarshal(request.Params[0], &args); err != nil {
+				t.Errorf("Failed to decode transaction: %v", err)
+				return
+			}
+			tx, err := args.ToTransaction()
+			if err != nil {
+				t.Errorf("Failed to build transaction: %v", err)
+				return
+			}
+			signed, err := types.SignTx(tx, types.LatestSignerForChainID(args.ChainID.ToInt()), key)
+			if err != nil {
+				t.Errorf("Failed to sign transaction: %v", err)
+				return
+			}
+			if result, err = signed.MarshalBinary(); err != nil {
+				t.Errorf("Failed to encode transaction: %v", err)
+				return
+			}
+		default:
+			t.Errorf("Unexpected request: %s with %d params", request.Method, len(request.Params))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": jsonrpcVersion,
+			"id":      request.Id,
+			"result":  result,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRemoteSignerSignMessage(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	server := newStandInSigner(t, key, "Bearer secret")
+
+	signer, err := NewRemoteSigner(RemoteSignerConfig{
+		Url:     server.URL,
+		Address: crypto.PubkeyToAddress(key.PublicKey),
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create remote signer: %v", err)
+	}
+
+	digest := crypto.Keccak256([]byte("paymaster hash"))
+	remoteSignature, err := SignMessage(context.Background(), signer, digest)
+	if err != nil {
+		t.Fatalf("Failed to sign message remotely: %v", err)
+	}
+	localSignature, err := SignMessage(context.Background(), NewPrivateKeySigner(key), digest)
+	if err != nil {
+		t.Fatalf("Failed to sign message locally: %v", err)
+	}
+	if hexutil.Encode(remoteSignature) != hexutil.Encode(localSignature) {
+		t.Errorf("Expected signature %x, got %x", localSignature, remoteSignature)
+	}
+}
+
+func TestRemoteSignerErrors(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	server := newStandInSigner(t, key, "Bearer secret")
+
+	signer, err := NewRemoteSigner(RemoteSignerConfig{
+		Url:     server.URL,
+		Address: crypto.PubkeyToAddress(key.PublicKey),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create remote signer: %v", err)
+	}
+
+	// Hash signing is disabled without a method
+	if _, err := signer.SignHash(context.Background(), make([]byte, 32)); err != ErrHashSigningUnsupported {
+		t.Errorf("Expected ErrHashSigningUnsupported, got %v", err)
+	}
+
+	// Missing authorization surfaces the http status
+	if _, err := signer.SignMessage(context.Background(), []byte("message")); err == nil {
+		t.Error("Expected error for unauthorized request")
+	}
+
+	// The JSON-RPC errors are wrapped
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"request denied"}}`))
+	}))
+	defer rejecting.Close()
+	signer, err = NewRemoteSigner(RemoteSignerConfig{
+		Url:     rejecting.URL,
+		Address: crypto.PubkeyToAddress(key.PublicKey),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create remote signer: %v", err)
+	}
+	_, err = signer.SignMessage(context.Background(), []byte("message"))
+	var rpcErr *BundlerError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32000 || rpcErr.Message != "request denied" {
+		t.Errorf("Expected the remote signer error to be wrapped, got %v", err)
+	}
+}
+
+func TestRemoteSignerPrefund(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	server := newStandInSigner(t, key, "Bearer secret")
+	signer, err := NewRemoteSigner(RemoteSignerConfig{
+		Url:     server.URL,
+		Address: crypto.PubkeyToAddress(key.PublicKey),
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create remote signer: %v", err)
+	}
+
+	receipt, err := json.Marshal(&types.Receipt{
+		Type:        types.DynamicFeeTxType,
+		Status:      types.ReceiptStatusSuccessful,
+		Logs:        []*types.Log{},
+		BlockNumber: big.NewInt(16),
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode receipt: %v", err)
+	}
+	calls := make(map[string]int)
+	client, stop := newRpcStub(t, map[string]string{
+		"eth_getBlockByNumber":      headerJson(t, big.NewInt(100)),
+		"eth_maxPriorityFeePerGas":  `"0x1"`,
+		"eth_getTransactionCount":   `"0x0"`,
+		"eth_getCode":               `"0x6000"`,
+		"eth_estimateGas":           `"0x5208"`,
+		"eth_sendRawTransaction":    `"0x0000000000000000000000000000000000000000000000000000000000000001"`,
+		"eth_getTransactionReceipt": string(receipt),
+	}, calls)
+	defer stop()
+	client.config.VerifyingSigner = signer
+	client.entrypoint, err = entrypoint.NewEntryPoint(common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032"), client.eth)
+	if err != nil {
+		t.Fatalf("Failed to create entrypoint: %v", err)
+	}
+
+	// The deposit transaction is signed remotely without raw hash signing
+	if _, err := client.Prefund(context.Background(), common.HexToAddress("0xaa"), big.NewInt(1e18)); err != nil {
+		t.Fatalf("Failed to prefund: %v", err)
+	}
+	if calls["eth_sendRawTransaction"] != 1 {
+		t.Errorf("Expected the signed transaction to be sent once, got %d", calls["eth_sendRawTransaction"])
+	}
+}
@@ -0,0 +1,230 @@
+package aasdk
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/account"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypoint"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/paymaster"
+)
+
+var (
+	// The selectors of the Solidity builtin errors.
+	errorSelector = [4]byte{0x08, 0xc3, 0x79, 0xa0} // Error(string)
+	panicSelector = [4]byte{0x4e, 0x48, 0x7b, 0x71} // Panic(uint256)
+
+	aaCodePattern = regexp.MustCompile(`^AA\d\d`)
+
+	revertErrorsOnce sync.Once
+	revertErrors     map[[4]byte]abi.Error
+)
+
+// EntryPointError is a decoded EntryPoint revert:
+// FailedOp, FailedOpWithRevert, PostOpReverted or SignatureValidationFailed.
+type EntryPointError struct {
+	Name       string         // the name of the EntryPoint error
+	OpIndex    *big.Int       // the index of the failed user operation, nil if not applicable
+	Reason     string         // the reason, e.g. "AA23 reverted"
+	Code       string         // the AA error code of the reason, e.g. "AA23", empty if none
+	Aggregator common.Address // the aggregator of SignatureValidationFailed
+	Inner      error          // the decoded inner revert of FailedOpWithRevert and PostOpReverted
+	Data       []byte         // the raw revert data
+}
+
+func (e *EntryPointError) Error() string {
+	var result string
+	switch e.Name {
+	case "SignatureValidationFailed":
+		result = fmt.Sprintf("%s: aggregator %s", e.Name, e.Aggregator.Hex())
+	case "PostOpReverted":
+		result = e.Name
+	default:
+		result = fmt.Sprintf("%s: op %s: %s", e.Name, e.OpIndex, e.Reason)
+	}
+	if e.Inner != nil {
+		result += fmt.Sprintf(": %v", e.Inner)
+	}
+	return result
+}
+
+// Unwrap returns the decoded inner revert.
+func (e *EntryPointError) Unwrap() error {
+	return e.Inner
+}
+
+// Entity returns the entity the AA error code blames: factory (AA1x), account (AA2x),
+// paymaster (AA3x), verification (AA4x), postOp (AA5x) or entrypoint (AA9x).
+// It returns an empty string if there is no code.
+func (e *EntryPointError) Entity() string {
+	if len(e.Code) != 4 {
+		return ""
+	}
+	switch e.Code[2] {
+	case '1':
+		return "factory"
+	case '2':
+		return "account"
+	case '3':
+		return "paymaster"
+	case '4':
+		return "verification"
+	case '5':
+		return "postOp"
+	case '9':
+		return "entrypoint"
+	}
+	return ""
+}
+
+// RevertError is a decoded revert other than the EntryPoint errors:
+// Error(string), Panic(uint256) or a custom error of the account or paymaster, e.g. ECDSAInvalidSignature.
+type RevertError struct {
+	Name   string   // Error, Panic or the custom error name, empty if unknown
+	Reason string   // the reason of Error(string)
+	Code   *big.Int // the code of Panic(uint256)
+	Args   []any    // the arguments of the custom error
+	Data   []byte   // the raw revert data
+}
+
+func (e *RevertError) Error() string {
+	switch e.Name {
+	case "":
+		return fmt.Sprintf("execution reverted: %s", hexutil.Encode(e.Data))
+	case "Error":
+		return fmt.Sprintf("execution reverted: %s", e.Reason)
+	case "Panic":
+		return fmt.Sprintf("panic: 0x%s", e.Code.Text(16))
+	}
+	if len(e.Args) == 0 {
+		return e.Name
+	}
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = fmt.Sprint(arg)
+	}
+	return fmt.Sprintf("%s(%s)", e.Name, strings.Join(args, ", "))
+}
+
+// DecodeRevert decodes the revert data into an *EntryPointError or a *RevertError.
+// It returns nil if the data is empty.
+func DecodeRevert(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if len(data) < 4 {
+		return &RevertError{Data: data}
+	}
+	var selector [4]byte
+	copy(selector[:], data[:4])
+
+	switch selector {
+	case errorSelector:
+		values, err := abi.Arguments{{Type: abi.Type{T: abi.StringTy}}}.Unpack(data[4:])
+		if err != nil || len(values) != 1 {
+			return &RevertError{Data: data}
+		}
+		reason, _ := values[0].(string)
+		return &RevertError{Name: "Error", Reason: reason, Data: data}
+	case panicSelector:
+		values, err := abi.Arguments{{Type: abi.Type{T: abi.UintTy, Size: 256}}}.Unpack(data[4:])
+		if err != nil || len(values) != 1 {
+			return &RevertError{Data: data}
+		}
+		code, _ := values[0].(*big.Int)
+		return &RevertError{Name: "Panic", Code: code, Data: data}
+	}
+
+	revertErrorsOnce.Do(loadRevertErrors)
+	abiErr, ok := revertErrors[selector]
+	if !ok {
+		return &RevertError{Data: data}
+	}
+	values, err := abiErr.Inputs.Unpack(data[4:])
+	if err != nil {
+		return &RevertError{Data: data}
+	}
+
+	switch abiErr.Name {
+	case "FailedOp", "FailedOpWithRevert":
+		e := &EntryPointError{Name: abiErr.Name, Data: data}
+		e.OpIndex, _ = values[0].(*big.Int)
+		e.Reason, _ = values[1].(string)
+		e.Code = aaCodePattern.FindString(e.Reason)
+		if abiErr.Name == "FailedOpWithRevert" {
+			inner, _ := values[2].([]byte)
+			e.Inner = DecodeRevert(inner)
+		}
+		return e
+	case "PostOpReverted":
+		e := &EntryPointError{Name: abiErr.Name, Data: data}
+		inner, _ := values[0].([]byte)
+		e.Inner = DecodeRevert(inner)
+		return e
+	case "SignatureValidationFailed":
+		e := &EntryPointError{Name: abiErr.Name, Data: data}
+		e.Aggregator, _ = values[0].(common.Address)
+		return e
+	}
+	return &RevertError{Name: abiErr.Name, Args: values, Data: data}
+}
+
+// RevertData extracts the revert data from an error returned by the node, e.g. by eth_call or eth_estimateGas.
+func RevertData(err error) ([]byte, bool) {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, false
+	}
+	switch data := dataErr.ErrorData().(type) {
+	case string:
+		decoded, err := hexutil.Decode(data)
+		if err != nil {
+			return nil, false
+		}
+		return decoded, true
+	case []byte:
+		return data, true
+	case hexutil.Bytes:
+		return data, true
+	}
+	return nil, false
+}
+
+// DecodeEntryPointError decodes the revert data carried by err.
+// It returns err unchanged if there is no revert data.
+func DecodeEntryPointError(err error) error {
+	data, ok := RevertData(err)
+	if !ok || len(data) == 0 {
+		return err
+	}
+	return DecodeRevert(data)
+}
+
+// loadRevertErrors indexes the custom errors of the EntryPoint, the account and the paymaster by selector.
+func loadRevertErrors() {
+	revertErrors = make(map[[4]byte]abi.Error)
+	for _, metadata := range []*bind.MetaData{
+		entrypoint.EntryPointMetaData,
+		account.SimpleAccountMetaData,
+		paymaster.VerifyingPaymasterMetaData,
+	} {
+		parsed, err := metadata.GetAbi()
+		if err != nil {
+			continue
+		}
+		for _, abiErr := range parsed.Errors {
+			var selector [4]byte
+			copy(selector[:], abiErr.ID[:4])
+			revertErrors[selector] = abiErr
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package aasdk
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/account"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypoint"
+)
+
+func TestDecodeRevert(t *testing.T) {
+	parsed, err := entrypoint.EntryPointMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to parse entrypoint ABI: %v", err)
+	}
+	inner, err := abi.Arguments{{Type: abi.Type{T: abi.StringTy}}}.Pack("not owner")
+	if err != nil {
+		t.Fatalf("Failed to pack inner revert: %v", err)
+	}
+	inner = append(errorSelector[:], inner...)
+
+	failedOp := parsed.Errors["FailedOpWithRevert"]
+	args, err := failedOp.Inputs.Pack(big.NewInt(2), "AA23 reverted", inner)
+	if err != nil {
+		t.Fatalf("Failed to pack FailedOpWithRevert: %v", err)
+	}
+	data := append(failedOp.ID[:4:4], args...)
+
+	decoded := DecodeRevert(data)
+	var epErr *EntryPointError
+	if !errors.As(decoded, &epErr) {
+		t.Fatalf("Expected EntryPointError, got %v", decoded)
+	}
+	if epErr.Name != "FailedOpWithRevert" || epErr.OpIndex.Int64() != 2 {
+		t.Errorf("Unexpected error %s for op %s", epErr.Name, epErr.OpIndex)
+	}
+	if epErr.Code != "AA23" || epErr.Entity() != "account" {
+		t.Errorf("Expected AA23 account error, got %s %s", epErr.Code, epErr.Entity())
+	}
+	var revertErr *RevertError
+	if !errors.As(decoded, &revertErr) {
+		t.Fatalf("Expected inner RevertError, got %v", epErr.Inner)
+	}
+	if revertErr.Name != "Error" || revertErr.Reason != "not owner" {
+		t.Errorf("Unexpected inner revert %v", revertErr)
+	}
+
+	// Unknown selectors are kept as raw data
+	if err := DecodeRevert([]byte{0xde, 0xad, 0xbe, 0xef}); !errors.As(err, &revertErr) || revertErr.Name != "" {
+		t.Errorf("Expected unknown RevertError, got %v", err)
+	}
+	if err := DecodeRevert(nil); err != nil {
+		t.Errorf("Expected nil for empty data, got %v", err)
+	}
+}
+
+// packError encodes the custom error of the ABI with its arguments.
+func packError(t *testing.T, parsed *abi.ABI, name string, args ...any) []byte {
+	abiErr, ok := parsed.Errors[name]
+	if !ok {
+		t.Fatalf("Unknown error %s", name)
+	}
+	packed, err := abiErr.Inputs.Pack(args...)
+	if err != nil {
+		t.Fatalf("Failed to pack %s: %v", name, err)
+	}
+	return append(abiErr.ID[:4:4], packed...)
+}
+
+func TestDecodeRevertErrors(t *testing.T) {
+	parsed, err := entrypoint.EntryPointMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to parse entrypoint ABI: %v", err)
+	}
+	accountAbi, err := account.SimpleAccountMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to parse account ABI: %v", err)
+	}
+	panicArgs, err := abi.Arguments{{Type: abi.Type{T: abi.UintTy, Size: 256}}}.Pack(big.NewInt(0x11))
+	if err != nil {
+		t.Fatalf("Failed to pack panic: %v", err)
+	}
+	panicData := append(panicSelector[:], panicArgs...)
+	aggregator := common.HexToAddress("0xaa")
+
+	// Panic(uint256)
+	var revertErr *RevertError
+	if err := DecodeRevert(panicData); !errors.As(err, &revertErr) || revertErr.Name != "Panic" || revertErr.Code.Int64() != 0x11 {
+		t.Errorf("Expected Panic(0x11), got %v", err)
+	} else if revertErr.Error() != "panic: 0x11" {
+		t.Errorf("Unexpected panic message %q", revertErr.Error())
+	}
+
+	// FailedOp
+	var epErr *EntryPointError
+	err = DecodeRevert(packError(t, parsed, "FailedOp", big.NewInt(0), "AA33 reverted"))
+	if !errors.As(err, &epErr) || epErr.Name != "FailedOp" || epErr.Code != "AA33" || epErr.Entity() != "paymaster" || epErr.Inner != nil {
+		t.Errorf("Expected AA33 FailedOp, got %v", err)
+	}
+
+	// FailedOpWithRevert wrapping a panic
+	err = DecodeRevert(packError(t, parsed, "FailedOpWithRevert", big.NewInt(1), "AA13 initCode failed or OOG", panicData))
+	if !errors.As(err, &epErr) || epErr.Name != "FailedOpWithRevert" || epErr.Entity() != "factory" {
+		t.Errorf("Expected AA13 FailedOpWithRevert, got %v", err)
+	} else if !errors.As(epErr.Inner, &revertErr) || revertErr.Name != "Panic" {
+		t.Errorf("Expected inner panic, got %v", epErr.Inner)
+	}
+
+	// PostOpReverted has no op index nor code
+	err = DecodeRevert(packError(t, parsed, "PostOpReverted", panicData))
+	if !errors.As(err, &epErr) || epErr.Name != "PostOpReverted" || epErr.OpIndex != nil || epErr.Code != "" {
+		t.Errorf("Expected PostOpReverted, got %v", err)
+	} else if epErr.Error() != "PostOpReverted: panic: 0x11" {
+		t.Errorf("Unexpected PostOpReverted message %q", epErr.Error())
+	}
+
+	// SignatureValidationFailed carries the aggregator
+	err = DecodeRevert(packError(t, parsed, "SignatureValidationFailed", aggregator))
+	if !errors.As(err, &epErr) || epErr.Name != "SignatureValidationFailed" || epErr.Aggregator != aggregator {
+		t.Errorf("Expected SignatureValidationFailed, got %v", err)
+	}
+
+	// The ECDSA custom errors of the account
+	err = DecodeRevert(packError(t, accountAbi, "ECDSAInvalidSignatureLength", big.NewInt(64)))
+	if !errors.As(err, &revertErr) || revertErr.Name != "ECDSAInvalidSignatureLength" || revertErr.Error() != "ECDSAInvalidSignatureLength(64)" {
+		t.Errorf("Expected ECDSAInvalidSignatureLength(64), got %v", err)
+	}
+	err = DecodeRevert(packError(t, accountAbi, "ECDSAInvalidSignature"))
+	if !errors.As(err, &revertErr) || revertErr.Name != "ECDSAInvalidSignature" || revertErr.Error() != "ECDSAInvalidSignature" {
+		t.Errorf("Expected ECDSAInvalidSignature, got %v", err)
+	}
+
+	// Truncated arguments are kept as raw data
+	truncated := packError(t, parsed, "FailedOp", big.NewInt(0), "AA33 reverted")[:20]
+	if err := DecodeRevert(truncated); !errors.As(err, &revertErr) || revertErr.Name != "" {
+		t.Errorf("Expected unknown RevertError for truncated data, got %v", err)
+	}
+}
+
+// dataError is an rpc.DataError, as returned by the node for reverts.
+type dataError struct {
+	data any
+}
+
+func (e *dataError) Error() string  { return "execution reverted" }
+func (e *dataError) ErrorData() any { return e.data }
+
+func TestRevertData(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected []byte
+		ok       bool
+	}{
+		{&dataError{"0xdeadbeef"}, []byte{0xde, 0xad, 0xbe, 0xef}, true},
+		{&dataError{[]byte{0x01}}, []byte{0x01}, true},
+		{&dataError{hexutil.Bytes{0x02}}, []byte{0x02}, true},
+		{&dataError{"not hex"}, nil, false},
+		{&dataError{nil}, nil, false},
+		{errors.New("execution reverted"), nil, false},
+	}
+	for _, test := range tests {
+		data, ok := RevertData(test.err)
+		if ok != test.ok || string(data) != string(test.expected) {
+			t.Errorf("RevertData(%v): expected %x %v, got %x %v", test.err, test.expected, test.ok, data, ok)
+		}
+	}
+
+	// Errors without revert data are returned unchanged
+	plain := errors.New("connection refused")
+	if err := DecodeEntryPointError(plain); err != plain {
+		t.Errorf("Expected the error unchanged, got %v", err)
+	}
+}
+
+func TestHandleOpsEntryPointError(t *testing.T) {
+	parsed, err := entrypoint.EntryPointMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to parse entrypoint ABI: %v", err)
+	}
+	revert := packError(t, parsed, "FailedOp", big.NewInt(0), "AA21 didn't pay prefund")
+	results := map[string]string{
+		"eth_getBlockByNumber":     headerJson(t, big.NewInt(100)),
+		"eth_maxPriorityFeePerGas": `"0x1"`,
+		"eth_getTransactionCount":  `"0x0"`,
+		"eth_getCode":              `"0x6000"`,
+	}
+	// The node rejects the gas estimation with the revert data
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Id     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		response := map[string]any{"jsonrpc": "2.0", "id": request.Id}
+		if request.Method == "eth_estimateGas" {
+			response["error"] = map[string]any{"code": 3, "message": "execution reverted", "data": hexutil.Bytes(revert)}
+		} else if result, ok := results[request.Method]; ok {
+			response["result"] = json.RawMessage(result)
+		} else {
+			response["result"] = nil
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+	eth, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create eth client: %v", err)
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	client := &Client{
+		chainId: big.NewInt(1),
+		config:  &Config{ExecutorSigners: NewRoundRobinSignerProvider([]*ecdsa.PrivateKey{key})},
+		eth:     eth,
+	}
+	client.entrypoint, err = entrypoint.NewEntryPoint(common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032"), eth)
+	if err != nil {
+		t.Fatalf("Failed to create entrypoint: %v", err)
+	}
+
+	op := PackUserOperation(&UserOperation{
+		Sender:               common.HexToAddress("0x01"),
+		Nonce:                big.NewInt(0),
+		CallData:             []byte{},
+		CallGasLimit:         big.NewInt(100000),
+		VerificationGasLimit: big.NewInt(100000),
+		PreVerificationGas:   big.NewInt(50000),
+		MaxFeePerGas:         big.NewInt(1),
+		MaxPriorityFeePerGas: big.NewInt(1),
+	})
+	_, _, err = client.HandleOps(context.Background(), []entrypoint.PackedUserOperation{op})
+	var epErr *EntryPointError
+	if !errors.As(err, &epErr) {
+		t.Fatalf("Expected EntryPointError, got %v", err)
+	}
+	if epErr.Name != "FailedOp" || epErr.Code != "AA21" || epErr.OpIndex.Int64() != 0 {
+		t.Errorf("Unexpected error %v", epErr)
+	}
+}
@@ -2,6 +2,7 @@ package aasdk
 
 import (
 	"crypto/ecdsa"
+	"fmt"
 	"sync"
 	"sync/atomic"
 )
@@ -57,3 +58,18 @@ func (r *RoundRobinSignerProvider) Count() int {
 
 	return len(r.signers)
 }
+
+// nextKey returns the next key of the rotator, with the reason when a rotator such as
+// KeystoreRotator reports why no key is available.
+func nextKey(rotator Rotator[*ecdsa.PrivateKey]) (*ecdsa.PrivateKey, error) {
+	if r, ok := rotator.(interface {
+		NextKey() (*ecdsa.PrivateKey, error)
+	}); ok {
+		return r.NextKey()
+	}
+	key := rotator.Next()
+	if key == nil {
+		return nil, fmt.Errorf("no key available")
+	}
+	return key, nil
+}
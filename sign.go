@@ -1,7 +1,7 @@
 package aasdk
 
 import (
-	"crypto/ecdsa"
+	"context"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/crypto"
@@ -11,15 +11,21 @@ const (
 	MessagePrefix = "\x19Ethereum Signed Message:\n"
 )
 
-// SignMessage signs a message with the provided private key.
-func SignMessage(privateKey *ecdsa.PrivateKey, message []byte) ([]byte, error) {
+// SignMessage signs a message with the provided signer.
+func SignMessage(ctx context.Context, signer Signer, message []byte) ([]byte, error) {
+	if messageSigner, ok := signer.(MessageSigner); ok {
+		signature, err := messageSigner.SignMessage(ctx, message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign message: %w", err)
+		}
+		return signature, nil
+	}
 	prefixedMessage := fmt.Sprintf("%s%d", MessagePrefix, len(message))
 	bytes := append([]byte(prefixedMessage), message...)
 	hash := crypto.Keccak256Hash([]byte(bytes))
-	signature, err := crypto.Sign(hash.Bytes(), privateKey)
+	signature, err := signer.SignHash(ctx, hash.Bytes())
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign message: %w", err)
 	}
-	signature[crypto.RecoveryIDOffset] += 27
 	return signature, nil
 }
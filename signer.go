@@ -0,0 +1,122 @@
+package aasdk
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Signer signs user operations and paymaster data on behalf of an account.
+// It allows the key to live outside of the process, e.g. in a KMS, HSM or remote signer.
+// Signatures are returned in the [R || S || V] format, where V is 27 or 28.
+type Signer interface {
+	// Address returns the address of the signing key.
+	Address() common.Address
+
+	// SignHash signs the given 32-byte digest as is, without any prefix.
+	SignHash(ctx context.Context, hash []byte) ([]byte, error)
+
+	// SignTypedData signs the EIP-712 typed data.
+	SignTypedData(ctx context.Context, typedData apitypes.TypedData) ([]byte, error)
+}
+
+// MessageSigner is implemented by signers that apply the EIP-191 prefix themselves,
+// e.g. remote signing services that don't expose raw hash signing.
+// SignMessage prefers it over Signer.SignHash when available.
+type MessageSigner interface {
+	// SignMessage signs the EIP-191 personal message.
+	SignMessage(ctx context.Context, message []byte) ([]byte, error)
+}
+
+// TransactionSigner is implemented by signers that sign transactions themselves,
+// e.g. remote signing services that don't expose raw hash signing.
+// The transactions sent by the client prefer it over Signer.SignHash when available.
+type TransactionSigner interface {
+	// SignTransaction returns the transaction signed for the chain.
+	SignTransaction(ctx context.Context, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error)
+}
+
+// AuthorizationSigner is implemented by signers that sign EIP-7702 authorizations themselves,
+// e.g. signing services that don't expose raw hash signing. Authorizations aren't EIP-712 typed data,
+// SignEip7702Auth prefers it over Signer.SignHash when available.
+type AuthorizationSigner interface {
+	// SignAuthorization signs the authorization tuple delegating the signer's EOA to the delegate.
+	SignAuthorization(ctx context.Context, chainId *big.Int, delegate common.Address, nonce uint64) ([]byte, error)
+}
+
+type privateKeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+var _ Signer = (*privateKeySigner)(nil)
+
+// NewPrivateKeySigner creates a Signer backed by an in-memory private key.
+func NewPrivateKeySigner(key *ecdsa.PrivateKey) Signer {
+	return &privateKeySigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+}
+
+// Address implements Signer.
+func (s *privateKeySigner) Address() common.Address {
+	return s.address
+}
+
+// SignHash implements Signer.
+func (s *privateKeySigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	signature, err := crypto.Sign(hash, s.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign hash: %w", err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return signature, nil
+}
+
+// SignTypedData implements Signer.
+func (s *privateKeySigner) SignTypedData(ctx context.Context, typedData apitypes.TypedData) ([]byte, error) {
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+	return s.SignHash(ctx, hash)
+}
+
+// newTransactor creates transaction options that sign transactions with the given signer.
+func newTransactor(ctx context.Context, signer Signer, chainId *big.Int) *bind.TransactOpts {
+	txSigner := types.LatestSignerForChainID(chainId)
+	from := signer.Address()
+	return &bind.TransactOpts{
+		From: from,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			if transactionSigner, ok := signer.(TransactionSigner); ok {
+				return transactionSigner.SignTransaction(ctx, tx, chainId)
+			}
+			signature, err := signer.SignHash(ctx, txSigner.Hash(tx).Bytes())
+			if err != nil {
+				return nil, err
+			}
+			if len(signature) != crypto.SignatureLength {
+				return nil, fmt.Errorf("invalid signature length: %d", len(signature))
+			}
+			// Transaction signatures expect the raw recovery id, the signer's slice is left as is.
+			signature = append([]byte{}, signature...)
+			if signature[crypto.RecoveryIDOffset] >= 27 {
+				signature[crypto.RecoveryIDOffset] -= 27
+			}
+			return tx.WithSignature(txSigner, signature)
+		},
+		Context: ctx,
+	}
+}
@@ -0,0 +1,137 @@
+package aasdk
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// recordingSigner returns the signatures of the private key signer with the raw recovery id, if set,
+// and records them.
+type recordingSigner struct {
+	Signer
+	rawV       bool
+	signatures [][]byte
+}
+
+func (s *recordingSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	signature, err := s.Signer.SignHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if s.rawV {
+		signature[crypto.RecoveryIDOffset] -= 27
+	}
+	s.signatures = append(s.signatures, signature)
+	return signature, nil
+}
+
+func TestPrivateKeySigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer := NewPrivateKeySigner(key)
+	if signer.Address() != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("Expected address %s, got %s", crypto.PubkeyToAddress(key.PublicKey).Hex(), signer.Address().Hex())
+	}
+
+	message := crypto.Keccak256([]byte("user operation"))
+	signature, err := SignMessage(context.Background(), signer, message)
+	if err != nil {
+		t.Fatalf("Failed to sign message: %v", err)
+	}
+	if len(signature) != crypto.SignatureLength {
+		t.Fatalf("Expected signature length %d, got %d", crypto.SignatureLength, len(signature))
+	}
+	if v := signature[crypto.RecoveryIDOffset]; v != 27 && v != 28 {
+		t.Errorf("Expected V to be 27 or 28, got %d", v)
+	}
+
+	// Recover the signer from the EIP-191 message hash
+	recoverable := append([]byte{}, signature...)
+	recoverable[crypto.RecoveryIDOffset] -= 27
+	pub, err := crypto.SigToPub(accounts.TextHash(message), recoverable)
+	if err != nil {
+		t.Fatalf("Failed to recover public key: %v", err)
+	}
+	if recovered := crypto.PubkeyToAddress(*pub); recovered != signer.Address() {
+		t.Errorf("Expected recovered address %s, got %s", signer.Address().Hex(), recovered.Hex())
+	}
+}
+
+func TestNewTransactor(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	chainId := big.NewInt(11155111)
+	to := common.HexToAddress("0xaa")
+	tx := types.NewTx(&types.DynamicFeeTx{ChainID: chainId, Nonce: 1, To: &to, Gas: 21000, GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1)})
+
+	for _, rawV := range []bool{false, true} {
+		signer := &recordingSigner{Signer: NewPrivateKeySigner(key), rawV: rawV}
+		opts := newTransactor(context.Background(), signer, chainId)
+		signed, err := opts.Signer(signer.Address(), tx)
+		if err != nil {
+			t.Fatalf("Failed to sign transaction with raw V %v: %v", rawV, err)
+		}
+		sender, err := types.Sender(types.LatestSignerForChainID(chainId), signed)
+		if err != nil || sender != signer.Address() {
+			t.Errorf("Expected sender %s with raw V %v, got %s, error %v", signer.Address().Hex(), rawV, sender.Hex(), err)
+		}
+
+		// The signer's signature isn't modified
+		v := signer.signatures[0][crypto.RecoveryIDOffset]
+		if (rawV && v > 1) || (!rawV && v < 27) {
+			t.Errorf("Expected the signer's V to be left as is with raw V %v, got %d", rawV, v)
+		}
+
+		if _, err := opts.Signer(common.HexToAddress("0xbb"), tx); err == nil {
+			t.Error("Expected error for another sender")
+		}
+	}
+}
+
+func TestSignUserOp(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer := NewPrivateKeySigner(key)
+	entrypointAddr := common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+	userOp := userOpV06()
+	packed := PackUserOperation(userOp)
+	packedV06 := PackUserOperationV06(userOp)
+	hashV06, err := GetUserOpHashV06(&packedV06, entrypointAddr, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("Failed to hash user operation: %v", err)
+	}
+	hashV07, err := GetUserOpHash(&packed, entrypointAddr, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("Failed to hash user operation: %v", err)
+	}
+
+	for version, expected := range map[EntryPointVersion]common.Hash{EntryPointV06: hashV06, EntryPointV07: hashV07} {
+		client := &Client{chainId: big.NewInt(1), config: &Config{Entrypoint: entrypointAddr}, version: version}
+		signature, hash, err := client.SignUserOp(context.Background(), &packed, signer)
+		if err != nil {
+			t.Fatalf("Failed to sign %s user operation: %v", version, err)
+		}
+		if hash != expected {
+			t.Errorf("Expected %s hash %s, got %s", version, expected.Hex(), hash.Hex())
+		}
+		recoverable := bytes.Clone(signature)
+		recoverable[crypto.RecoveryIDOffset] -= 27
+		pub, err := crypto.SigToPub(accounts.TextHash(hash.Bytes()), recoverable)
+		if err != nil || crypto.PubkeyToAddress(*pub) != signer.Address() {
+			t.Errorf("Expected the %s hash to be signed by %s, error %v", version, signer.Address().Hex(), err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package aasdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Sponsorship is how the gas of a user operation is paid.
+type Sponsorship string
+
+const (
+	// SponsorshipNone lets the account pay with its entrypoint deposit or its balance.
+	SponsorshipNone Sponsorship = "none"
+	// SponsorshipVerifyingPaymaster sponsors with Config.PaymasterAddress, signed by Config.VerifyingSigner.
+	SponsorshipVerifyingPaymaster Sponsorship = "verifying_paymaster"
+	// SponsorshipPaymasterService sponsors with a PaymasterProvider, e.g. an ERC-7677 paymaster service.
+	// Without provider, the paymaster fields set on the user operation are used as is.
+	SponsorshipPaymasterService Sponsorship = "paymaster_service"
+)
+
+var (
+	// ErrInsufficientPrefund is returned when an unsponsored account can't pay the prefund of the user operation.
+	ErrInsufficientPrefund = errors.New("insufficient prefund")
+)
+
+// FillOptions configures FillAndSignWithOptions for a single user operation.
+type FillOptions struct {
+	// The sponsorship of the user operation, defaults to Config.Sponsorship.
+	Sponsorship Sponsorship
+	// Whether to skip the prefund check of unsponsored user operations.
+	SkipPrefundCheck bool
+	// The provider of SponsorshipPaymasterService, defaults to Config.PaymasterProvider.
+	PaymasterProvider PaymasterProvider
+	// The context passed to the paymaster provider, e.g. a sponsorship policy id.
+	PaymasterContext map[string]any
+	// The validity window of the verifying paymaster signature, defaults to Config.PaymasterValidity from signing.
+	PaymasterValidity *PaymasterValidity
+}
+
+// sponsorship returns the sponsorship of the options, or the default of the config.
+// Without a configured sponsorship, the verifying paymaster is used if its address is set.
+func (c *Client) sponsorship(opts FillOptions) Sponsorship {
+	if opts.Sponsorship != "" {
+		return opts.Sponsorship
+	}
+	if c.config.Sponsorship != "" {
+		return c.config.Sponsorship
+	}
+	if c.config.PaymasterAddress != (common.Address{}) {
+		return SponsorshipVerifyingPaymaster
+	}
+	return SponsorshipNone
+}
+
+// RequiredPrefund returns the maximum gas cost of the user operation, prefunded by the account
+// or the paymaster before the execution.
+func RequiredPrefund(userOp *UserOperation, version EntryPointVersion) *big.Int {
+	requiredGas := new(big.Int)
+	add := func(gas *big.Int) {
+		if gas != nil {
+			requiredGas.Add(requiredGas, gas)
+		}
+	}
+	add(userOp.CallGasLimit)
+	add(userOp.PreVerificationGas)
+	if version == EntryPointV06 {
+		// The V0.6.0 verification gas limit also covers the paymaster validation and postOp.
+		mul := int64(1)
+		if userOp.Paymaster != (common.Address{}) {
+			mul = 3
+		}
+		if userOp.VerificationGasLimit != nil {
+			add(new(big.Int).Mul(userOp.VerificationGasLimit, big.NewInt(mul)))
+		}
+	} else {
+		add(userOp.VerificationGasLimit)
+		if userOp.Paymaster != (common.Address{}) {
+			add(userOp.PaymasterVerificationGasLimit)
+			add(userOp.PaymasterPostOpGasLimit)
+		}
+	}
+	if userOp.MaxFeePerGas == nil {
+		return new(big.Int)
+	}
+	return requiredGas.Mul(requiredGas, userOp.MaxFeePerGas)
+}
+
+// checkPrefund checks if the entrypoint deposit and the balance of the sender cover the prefund.
+// It returns an error wrapping ErrInsufficientPrefund otherwise.
+func (c *Client) checkPrefund(ctx context.Context, userOp *UserOperation) error {
+	prefund := RequiredPrefund(userOp, c.version)
+	deposit, err := c.entrypoint.BalanceOf(&bind.CallOpts{Context: ctx}, userOp.Sender)
+	if err != nil {
+		return fmt.Errorf("error getting entrypoint deposit: %v", err)
+	}
+	if deposit.Cmp(prefund) >= 0 {
+		return nil
+	}
+	balance, err := c.eth.BalanceAt(ctx, userOp.Sender, nil)
+	if err != nil {
+		return fmt.Errorf("error getting account balance: %v", err)
+	}
+	if available := new(big.Int).Add(deposit, balance); available.Cmp(prefund) < 0 {
+		return fmt.Errorf("%w: required %s, deposit %s, balance %s", ErrInsufficientPrefund, prefund, deposit, balance)
+	}
+	return nil
+}
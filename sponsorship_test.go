@@ -0,0 +1,29 @@
+package aasdk
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestRequiredPrefund(t *testing.T) {
+	userOp := &UserOperation{
+		CallGasLimit:                  big.NewInt(100),
+		VerificationGasLimit:          big.NewInt(200),
+		PreVerificationGas:            big.NewInt(50),
+		PaymasterVerificationGasLimit: big.NewInt(30),
+		PaymasterPostOpGasLimit:       big.NewInt(20),
+		MaxFeePerGas:                  big.NewInt(2),
+	}
+	if prefund := RequiredPrefund(userOp, EntryPointV07); prefund.Int64() != 700 {
+		t.Errorf("Expected prefund 700 without paymaster, got %s", prefund)
+	}
+	userOp.Paymaster = common.HexToAddress("0xbb")
+	if prefund := RequiredPrefund(userOp, EntryPointV07); prefund.Int64() != 800 {
+		t.Errorf("Expected prefund 800 with paymaster, got %s", prefund)
+	}
+	if prefund := RequiredPrefund(userOp, EntryPointV06); prefund.Int64() != 1500 {
+		t.Errorf("Expected V0.6.0 prefund 1500 with paymaster, got %s", prefund)
+	}
+}
@@ -0,0 +1,152 @@
+package aasdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultRequestTimeout is the timeout of JSON-RPC requests when no http client is provided.
+	DefaultRequestTimeout = 30 * time.Second
+
+	// maxErrorBodyLength caps the response body kept in HTTPError.
+	maxErrorBodyLength = 1024
+)
+
+// errBatchUnsupported is returned when the server doesn't answer a batch request with an array.
+var errBatchUnsupported = errors.New("batch requests unsupported")
+
+// HTTPError is returned when a JSON-RPC server responds with a non-2xx status.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Body       []byte // the beginning of the response body
+}
+
+func (e *HTTPError) Error() string {
+	body := strings.TrimSpace(string(e.Body))
+	if body == "" {
+		return fmt.Sprintf("unexpected http status %s", e.Status)
+	}
+	return fmt.Sprintf("unexpected http status %s: %s", e.Status, body)
+}
+
+// transport makes JSON-RPC calls over HTTP.
+type transport struct {
+	id      atomic.Uint64 // unique id for the requests
+	url     string
+	headers map[string]string
+	http    *http.Client
+}
+
+// newTransport creates a transport to the url.
+// It uses a client with DefaultRequestTimeout if httpClient is nil.
+func newTransport(url string, headers map[string]string, httpClient *http.Client) *transport {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultRequestTimeout}
+	}
+	return &transport{
+		url:     url,
+		headers: headers,
+		http:    httpClient,
+	}
+}
+
+// call makes a JSON-RPC call and returns the raw response body.
+// The request is bound to ctx, non-2xx responses are returned as *HTTPError.
+func (t *transport) call(ctx context.Context, method string, params []any) ([]byte, error) {
+	payloadBytes, err := json.Marshal(t.newRequest(method, params))
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling payload: %v", err)
+	}
+	return t.post(ctx, payloadBytes)
+}
+
+// batchCall makes the JSON-RPC calls in a single batch request and returns the raw responses in the order of params.
+// The responses are correlated by id, a missing response is nil.
+// It returns errBatchUnsupported if the server doesn't answer with an array.
+func (t *transport) batchCall(ctx context.Context, method string, params [][]any) ([][]byte, error) {
+	requests := make([]map[string]any, len(params))
+	ids := make([]uint64, len(params))
+	for i := range params {
+		requests[i] = t.newRequest(method, params[i])
+		ids[i] = requests[i]["id"].(uint64)
+	}
+	payloadBytes, err := json.Marshal(requests)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling payload: %v", err)
+	}
+	body, err := t.post(ctx, payloadBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []json.RawMessage
+	if err := json.Unmarshal(body, &responses); err != nil {
+		return nil, errBatchUnsupported
+	}
+	byId := make(map[uint64][]byte, len(responses))
+	for _, response := range responses {
+		var header struct {
+			Id *uint64 `json:"id"`
+		}
+		if err := json.Unmarshal(response, &header); err != nil || header.Id == nil {
+			continue
+		}
+		byId[*header.Id] = response
+	}
+	results := make([][]byte, len(params))
+	for i, id := range ids {
+		results[i] = byId[id]
+	}
+	return results, nil
+}
+
+func (t *transport) newRequest(method string, params []any) map[string]any {
+	if params == nil {
+		params = []any{}
+	}
+	return map[string]any{
+		"jsonrpc": jsonrpcVersion,
+		"id":      t.id.Add(1),
+		"method":  method,
+		"params":  params,
+	}
+}
+
+// post sends the payload and returns the response body.
+func (t *transport) post(ctx context.Context, payloadBytes []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(payloadBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	res, err := t.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		if len(body) > maxErrorBodyLength {
+			body = body[:maxErrorBodyLength]
+		}
+		return nil, &HTTPError{StatusCode: res.StatusCode, Status: res.Status, Body: body}
+	}
+	return body, nil
+}
@@ -0,0 +1,101 @@
+package aasdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTransportCall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("X-Api-Key") {
+		case "secret":
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":[]}`))
+		case "slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			http.Error(w, "invalid api key", http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	// Custom headers are sent with the request
+	tr := newTransport(server.URL, map[string]string{"X-Api-Key": "secret"}, nil)
+	if _, err := tr.call(context.Background(), "eth_supportedEntryPoints", nil); err != nil {
+		t.Fatalf("Failed to call: %v", err)
+	}
+
+	// Non-2xx responses are surfaced as HTTPError
+	tr = newTransport(server.URL, nil, server.Client())
+	_, err := tr.call(context.Background(), "eth_supportedEntryPoints", nil)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Expected HTTPError, got %v", err)
+	}
+	if httpErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, httpErr.StatusCode)
+	}
+
+	// The request is bound to the context
+	tr = newTransport(server.URL, map[string]string{"X-Api-Key": "slow"}, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := tr.call(ctx, "eth_supportedEntryPoints", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestTransportBatchCall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requests []struct {
+			Id     uint64   `json:"id"`
+			Params []string `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requests); err != nil {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"batch not supported"}}`))
+			return
+		}
+		// Answer in reverse order, skipping the first request
+		var responses []map[string]any
+		for i := len(requests) - 1; i > 0; i-- {
+			responses = append(responses, map[string]any{"jsonrpc": "2.0", "id": requests[i].Id, "result": requests[i].Params[0]})
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+	defer server.Close()
+
+	tr := newTransport(server.URL, nil, nil)
+	tr.call(context.Background(), "eth_chainId", nil) // ids don't start at 1
+	responses, err := tr.batchCall(context.Background(), "echo", [][]any{{"a"}, {"b"}, {"c"}})
+	if err != nil {
+		t.Fatalf("Failed to batch call: %v", err)
+	}
+	if responses[0] != nil {
+		t.Errorf("Expected missing response to be nil, got %s", responses[0])
+	}
+	for i, expected := range []string{"b", "c"} {
+		var response jsonRpcResponse[string]
+		if err := json.Unmarshal(responses[i+1], &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response.Result != expected {
+			t.Errorf("Expected result %s, got %s", expected, response.Result)
+		}
+	}
+}
+
+func TestTransportBatchUnsupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"batch not supported"}}`))
+	}))
+	defer server.Close()
+
+	tr := newTransport(server.URL, nil, nil)
+	if _, err := tr.batchCall(context.Background(), "echo", [][]any{{"a"}}); !errors.Is(err, errBatchUnsupported) {
+		t.Errorf("Expected errBatchUnsupported, got %v", err)
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"math/big"
+	"net/http"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 var (
@@ -21,25 +24,95 @@ var (
 	DefaultPaymasterPostOpGasLimit       = int64(100)
 )
 
+// EntryPointVersion is the version of the ERC-4337 EntryPoint contract.
+type EntryPointVersion string
+
+const (
+	EntryPointV06 EntryPointVersion = "v0.6"
+	EntryPointV07 EntryPointVersion = "v0.7"
+	EntryPointV08 EntryPointVersion = "v0.8"
+)
+
 type Config struct {
 	// The url of node.
 	NodeUrl string
 	// The url of bundler.
 	BundlerUrl string
+	// The fallback bundler urls, used in order after BundlerUrl when it fails. The bundlers failing often are tried last.
+	BundlerUrls []string
+	// The retry and circuit breaking policy across the bundler urls, the zero fields default to DefaultFailoverPolicy.
+	Failover FailoverPolicy
+	// The headers added to every bundler request, e.g. API keys or authorization.
+	BundlerHeaders map[string]string
+	// The http client of bundler requests.
+	// It's optional, a client with DefaultRequestTimeout is used when nil.
+	HttpClient *http.Client
 	// The interval to query the receipt.
 	WaitReceiptInterval time.Duration
 	// The entrypoint address.
-	// Currently, it supports Entrypoint V0.7.0
+	// Currently, it supports Entrypoint V0.6.0, V0.7.0 and V0.8.0
 	Entrypoint common.Address
+	// The version of the entrypoint, defaults to EntryPointV07.
+	EntrypointVersion EntryPointVersion
 	// The simple account factory address.
 	AccountFactory common.Address
+	// The creation code of the ERC1967Proxy deployed by the account factory.
+	// It's optional, when set the account addresses are computed offline with CREATE2 instead of calling the factory.
+	// It must be the exact type(ERC1967Proxy).creationCode compiled into the deployed factory, any other code
+	// yields wrong addresses without error: fetch it once with FetchAccountProxyCreationCode.
+	AccountProxyCreationCode []byte
+	// The account implementation of the factory, used with AccountProxyCreationCode.
+	// It's optional, it's fetched once from the factory when empty.
+	AccountImplementation common.Address
+	// Whether to cross-check the account addresses computed offline against the factory.
+	VerifyAccountAddress bool
+	// The account implementation the owner EOA delegates to with EIP-7702.
+	// It's optional and only used with EntryPointV08, user operations sent from the owner EOA
+	// carry a signed authorization instead of deploying an account with the factory.
+	Eip7702Delegate common.Address
 	// The verifying paymaster address.
 	PaymasterAddress common.Address
+	// The default sponsorship of the user operations, FillOptions can override it per user operation.
+	// Defaults to SponsorshipVerifyingPaymaster if PaymasterAddress is set, SponsorshipNone otherwise.
+	Sponsorship Sponsorship
+	// The paymaster provider of SponsorshipPaymasterService, e.g. a PaymasterClient.
+	PaymasterProvider PaymasterProvider
 	// The account verifying Paymaster requests.
-	VerifyingSigner *ecdsa.PrivateKey
+	VerifyingSigner Signer
+	// How long the verifying paymaster signatures are valid after signing, defaults to DefaultPaymasterValidity.
+	PaymasterValidity time.Duration
 	// The account that will sign the user operation.
 	// It's needed when call directly to Entrypoint contract.
 	ExecutorSigners Rotator[*ecdsa.PrivateKey]
+	// Whether to estimate the gas limits with the bundler when filling the user operation.
+	// When disabled, the gas limits of the user operation are used as is.
+	EstimateGas bool
+	// The safety multipliers applied to the estimated gas limits, e.g. DefaultGasMultipliers.
+	GasMultipliers GasMultipliers
+	// Whether to fill the fees of the user operation with the fee oracle.
+	// When disabled, the fees of the user operation are used as is.
+	EstimateFees bool
+	// The fee preset, defaults to FeeSpeedStandard.
+	FeeSpeed FeeSpeed
+	// The bundler method consulted for fees, RundlerMaxPriorityFeeMethod or PimlicoUserOpGasPriceMethod.
+	// It's optional, the fees are derived from the node only when empty.
+	FeeBundlerMethod string
+	// The custom fee oracle. It's optional, the client suggests the fees itself when nil.
+	FeeOracle FeeOracle
+}
+
+// bundlerUrls returns BundlerUrl followed by BundlerUrls, without empty or duplicate urls.
+func (c *Config) bundlerUrls() []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, url := range append([]string{c.BundlerUrl}, c.BundlerUrls...) {
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		urls = append(urls, url)
+	}
+	return urls
 }
 
 func NewUserOpWithDefault(sender common.Address, calldata []byte, salt *big.Int) *UserOperation {
@@ -58,25 +131,47 @@ func NewUserOpWithDefault(sender common.Address, calldata []byte, salt *big.Int)
 }
 
 // UserOperation represents the base structure for operations by ERC-4337
-// Supported EntryPoint V0.7.0
+// Supported EntryPoint V0.6.0, V0.7.0 and V0.8.0
 type UserOperation struct {
-	Sender                        common.Address `json:"sender"`
-	Nonce                         *big.Int       `json:"nonce"`
-	CallData                      []byte         `json:"callData"`
-	CallGasLimit                  *big.Int       `json:"callGasLimit"`
-	VerificationGasLimit          *big.Int       `json:"verificationGasLimit"`
-	PreVerificationGas            *big.Int       `json:"preVerificationGas"`
-	MaxFeePerGas                  *big.Int       `json:"maxFeePerGas"`
-	MaxPriorityFeePerGas          *big.Int       `json:"maxPriorityFeePerGas"`
-	Signature                     []byte         `json:"signature"`
-	Paymaster                     common.Address `json:"paymaster"`
-	PaymasterData                 []byte         `json:"paymasterData"`
-	PaymasterVerificationGasLimit *big.Int       `json:"paymasterVerificationGasLimit"`
-	PaymasterPostOpGasLimit       *big.Int       `json:"paymasterPostOpGasLimit"`
-	Factory                       common.Address `json:"factory"`
-	FactoryData                   []byte         `json:"factoryData"`
-	InitCode                      []byte         `json:"initCode"`
-	Salt                          *big.Int
+	Sender                        common.Address
+	Nonce                         *big.Int
+	CallData                      []byte
+	CallGasLimit                  *big.Int
+	VerificationGasLimit          *big.Int
+	PreVerificationGas            *big.Int
+	MaxFeePerGas                  *big.Int
+	MaxPriorityFeePerGas          *big.Int
+	Signature                     []byte
+	Paymaster                     common.Address
+	PaymasterData                 []byte
+	PaymasterVerificationGasLimit *big.Int
+	PaymasterPostOpGasLimit       *big.Int
+	Factory                       common.Address
+	FactoryData                   []byte
+	InitCode                      []byte
+	Eip7702Auth                   *Eip7702Auth
+	Salt                          *big.Int // the salt of the account, not part of the RPC format
+}
+
+// MarshalJSON implements json.Marshaler, encoding the user operation in the V0.7.0 bundler RPC format.
+// The initCode is only encoded when there is no factory. A V0.6.0 user operation is encoded with
+// factory, factoryData, paymaster and paymasterData too, use ToBodyV06 for the V0.6.0 RPC format.
+func (u UserOperation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newRpcUserOperation(&u))
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding the user operation from the V0.6.0 or V0.7.0 RPC format.
+func (u *UserOperation) UnmarshalJSON(b []byte) error {
+	var rpcOp rpcUserOperation
+	if err := json.Unmarshal(b, &rpcOp); err != nil {
+		return err
+	}
+	userOp, err := rpcOp.toUserOperation()
+	if err != nil {
+		return err
+	}
+	*u = *userOp
+	return nil
 }
 
 // ToBody converts the UserOperation to a map of strings.
@@ -131,6 +226,135 @@ func (u *UserOperation) ToBody() map[string]string {
 	return body
 }
 
+// rpcUserOperation is a user operation in the bundler RPC format, either V0.6.0 or V0.7.0.
+type rpcUserOperation struct {
+	Sender                        common.Address  `json:"sender"`
+	Nonce                         *hexutil.Big    `json:"nonce,omitempty"`
+	Factory                       *common.Address `json:"factory,omitempty"`
+	FactoryData                   hexutil.Bytes   `json:"factoryData,omitempty"`
+	InitCode                      hexutil.Bytes   `json:"initCode,omitempty"`
+	CallData                      hexutil.Bytes   `json:"callData"`
+	CallGasLimit                  *hexutil.Big    `json:"callGasLimit,omitempty"`
+	VerificationGasLimit          *hexutil.Big    `json:"verificationGasLimit,omitempty"`
+	PreVerificationGas            *hexutil.Big    `json:"preVerificationGas,omitempty"`
+	MaxFeePerGas                  *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas          *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	Paymaster                     *common.Address `json:"paymaster,omitempty"`
+	PaymasterVerificationGasLimit *hexutil.Big    `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       *hexutil.Big    `json:"paymasterPostOpGasLimit,omitempty"`
+	PaymasterData                 hexutil.Bytes   `json:"paymasterData,omitempty"`
+	PaymasterAndData              hexutil.Bytes   `json:"paymasterAndData,omitempty"`
+	Signature                     hexutil.Bytes   `json:"signature"`
+	Eip7702Auth                   *rpcEip7702Auth `json:"eip7702Auth,omitempty"`
+}
+
+// rpcEip7702Auth is an EIP-7702 authorization in the bundler RPC format.
+type rpcEip7702Auth struct {
+	ChainId *hexutil.Big   `json:"chainId"`
+	Address common.Address `json:"address"`
+	Nonce   hexutil.Uint64 `json:"nonce"`
+	YParity hexutil.Uint64 `json:"yParity"`
+	R       *hexutil.Big   `json:"r"`
+	S       *hexutil.Big   `json:"s"`
+}
+
+// newRpcUserOperation converts the user operation to the RPC format.
+func newRpcUserOperation(u *UserOperation) *rpcUserOperation {
+	rpcOp := &rpcUserOperation{
+		Sender:                        u.Sender,
+		Nonce:                         (*hexutil.Big)(u.Nonce),
+		CallData:                      u.CallData,
+		CallGasLimit:                  (*hexutil.Big)(u.CallGasLimit),
+		VerificationGasLimit:          (*hexutil.Big)(u.VerificationGasLimit),
+		PreVerificationGas:            (*hexutil.Big)(u.PreVerificationGas),
+		MaxFeePerGas:                  (*hexutil.Big)(u.MaxFeePerGas),
+		MaxPriorityFeePerGas:          (*hexutil.Big)(u.MaxPriorityFeePerGas),
+		PaymasterVerificationGasLimit: (*hexutil.Big)(u.PaymasterVerificationGasLimit),
+		PaymasterPostOpGasLimit:       (*hexutil.Big)(u.PaymasterPostOpGasLimit),
+		PaymasterData:                 u.PaymasterData,
+		Signature:                     u.Signature,
+	}
+	if rpcOp.CallData == nil {
+		rpcOp.CallData = hexutil.Bytes{}
+	}
+	if rpcOp.Signature == nil {
+		rpcOp.Signature = hexutil.Bytes{}
+	}
+	if u.Factory != (common.Address{}) {
+		factory := u.Factory
+		rpcOp.Factory = &factory
+		rpcOp.FactoryData = u.FactoryData
+	} else {
+		rpcOp.InitCode = u.InitCode
+	}
+	if u.Paymaster != (common.Address{}) {
+		paymaster := u.Paymaster
+		rpcOp.Paymaster = &paymaster
+	}
+	if auth := u.Eip7702Auth; auth != nil {
+		rpcOp.Eip7702Auth = &rpcEip7702Auth{
+			ChainId: (*hexutil.Big)(auth.ChainId),
+			Address: auth.Address,
+			Nonce:   hexutil.Uint64(auth.Nonce),
+			YParity: hexutil.Uint64(auth.YParity),
+			R:       (*hexutil.Big)(auth.R),
+			S:       (*hexutil.Big)(auth.S),
+		}
+	}
+	return rpcOp
+}
+
+// toUserOperation converts the RPC user operation, splitting the V0.6.0 initCode and paymasterAndData.
+// An initCode or paymasterAndData shorter than an address is an error.
+func (r *rpcUserOperation) toUserOperation() (*UserOperation, error) {
+	userOp := &UserOperation{
+		Sender:                        r.Sender,
+		Nonce:                         (*big.Int)(r.Nonce),
+		CallData:                      r.CallData,
+		CallGasLimit:                  (*big.Int)(r.CallGasLimit),
+		VerificationGasLimit:          (*big.Int)(r.VerificationGasLimit),
+		PreVerificationGas:            (*big.Int)(r.PreVerificationGas),
+		MaxFeePerGas:                  (*big.Int)(r.MaxFeePerGas),
+		MaxPriorityFeePerGas:          (*big.Int)(r.MaxPriorityFeePerGas),
+		PaymasterVerificationGasLimit: (*big.Int)(r.PaymasterVerificationGasLimit),
+		PaymasterPostOpGasLimit:       (*big.Int)(r.PaymasterPostOpGasLimit),
+		PaymasterData:                 r.PaymasterData,
+		FactoryData:                   r.FactoryData,
+		InitCode:                      r.InitCode,
+		Signature:                     r.Signature,
+	}
+	if r.Factory != nil {
+		userOp.Factory = *r.Factory
+		userOp.InitCode = append(r.Factory.Bytes(), r.FactoryData...)
+	} else if len(r.InitCode) > 0 {
+		if len(r.InitCode) < common.AddressLength {
+			return nil, fmt.Errorf("initCode too short: %d bytes, expected at least %d", len(r.InitCode), common.AddressLength)
+		}
+		userOp.Factory = common.BytesToAddress(r.InitCode[:common.AddressLength])
+		userOp.FactoryData = r.InitCode[common.AddressLength:]
+	}
+	if r.Paymaster != nil {
+		userOp.Paymaster = *r.Paymaster
+	} else if len(r.PaymasterAndData) > 0 {
+		if len(r.PaymasterAndData) < common.AddressLength {
+			return nil, fmt.Errorf("paymasterAndData too short: %d bytes, expected at least %d", len(r.PaymasterAndData), common.AddressLength)
+		}
+		userOp.Paymaster = common.BytesToAddress(r.PaymasterAndData[:common.AddressLength])
+		userOp.PaymasterData = r.PaymasterAndData[common.AddressLength:]
+	}
+	if auth := r.Eip7702Auth; auth != nil {
+		userOp.Eip7702Auth = &Eip7702Auth{
+			ChainId: (*big.Int)(auth.ChainId),
+			Address: auth.Address,
+			Nonce:   uint64(auth.Nonce),
+			YParity: uint8(auth.YParity),
+			R:       (*big.Int)(auth.R),
+			S:       (*big.Int)(auth.S),
+		}
+	}
+	return userOp, nil
+}
+
 // TxDetail represents the details of a transaction for a user operation
 type TxDetail struct {
 	Target               common.Address // The target address of the transaction
@@ -142,31 +366,14 @@ type TxDetail struct {
 	Nonce                *big.Int       // The nonce for the transaction (optional)
 }
 
-type receipt struct {
-	BlockHash         common.Hash    `json:"blockHash"`
-	BlockNumber       string         `json:"blockNumber"`
-	From              common.Address `json:"from"`
-	CumulativeGasUsed string         `json:"cumulativeGasUsed"`
-	GasUsed           string         `json:"gasUsed"`
-	Logs              []*types.Log   `json:"logs"`
-	LogsBloom         types.Bloom    `json:"logsBloom"`
-	TransactionHash   common.Hash    `json:"transactionHash"`
-	TransactionIndex  string         `json:"transactionIndex"`
-	EffectiveGasPrice string         `json:"effectiveGasPrice"`
-}
-
-type UserOpReceipt struct {
-	UserOpHash    common.Hash    `json:"userOpHash"`
-	Sender        common.Address `json:"sender"`
-	Paymaster     common.Address `json:"paymaster"`
-	Nonce         string         `json:"nonce"`
-	Success       bool           `json:"success"`
-	ActualGasCost string         `json:"actualGasCost"`
-	ActualGasUsed string         `json:"actualGasUsed"`
-	From          common.Address `json:"from"`
-	Receipt       *receipt       `json:"receipt"`
-	Logs          []*types.Log   `json:"logs"`
-	ReturnData    []byte         `json:"returnData"`
+// UserOperationByHash is the result of eth_getUserOperationByHash.
+// The block and transaction fields are empty while the user operation is pending.
+type UserOperationByHash struct {
+	UserOperation   *UserOperation
+	EntryPoint      common.Address
+	BlockNumber     *big.Int
+	BlockHash       common.Hash
+	TransactionHash common.Hash
 }
 
 // GasEstimates provides estimate values for all gas fields in a UserOperation.
@@ -177,6 +384,9 @@ type GasEstimates struct {
 	VerificationGas      *big.Int `json:"verificationGas"`
 	MaxFeePerGas         *big.Int `json:"maxFeePerGas"`
 	MaxPriorityFeePerGas *big.Int `json:"maxPriorityFeePerGas"`
+
+	PaymasterVerificationGasLimit *big.Int `json:"paymasterVerificationGasLimit"`
+	PaymasterPostOpGasLimit       *big.Int `json:"paymasterPostOpGasLimit"`
 }
 
 type BaseAccount interface {
@@ -186,7 +396,7 @@ type BaseAccount interface {
 
 type Bundler interface {
 	// SendUserOp sends the user operation to the bundler.
-	SendUserOp(ctx context.Context, userOp *UserOperation, signer *ecdsa.PrivateKey) (common.Hash, error)
+	SendUserOp(ctx context.Context, userOp *UserOperation, signer Signer) (common.Hash, error)
 
 	// EstimateUserOpGas estimates the gas needed for the user operation.
 	EstimateUserOpGas(ctx context.Context, userOp *UserOperation) (*GasEstimates, error)
@@ -197,3 +407,33 @@ type Bundler interface {
 	// SupportedEntryPoints returns the supported entry points for the bundler.
 	SupportedEntryPoints(ctx context.Context) ([]common.Address, error)
 }
+
+// DebugBundler is a Bundler with the lookup and debug_bundler_* methods, as implemented by Client.
+// It's kept apart from Bundler so that the existing Bundler implementations don't have to provide them.
+type DebugBundler interface {
+	Bundler
+
+	// GetUserOperationByHash returns the user operation and where it was included, nil if unknown.
+	GetUserOperationByHash(ctx context.Context, userOpHash common.Hash) (*UserOperationByHash, error)
+
+	// BundlerChainId returns the chain ID of the bundler.
+	BundlerChainId(ctx context.Context) (*big.Int, error)
+
+	// DebugClearState clears the mempool and the reputations of the bundler.
+	DebugClearState(ctx context.Context) error
+
+	// DebugDumpMempool returns the user operations in the mempool of the entrypoint.
+	DebugDumpMempool(ctx context.Context) ([]*UserOperation, error)
+
+	// DebugSendBundleNow forces the bundler to send a bundle and returns the transaction hash.
+	DebugSendBundleNow(ctx context.Context) (common.Hash, error)
+
+	// DebugSetBundlingMode sets the bundling mode of the bundler.
+	DebugSetBundlingMode(ctx context.Context, mode BundlingMode) error
+
+	// DebugSetReputation sets the reputations of the entities for the entrypoint.
+	DebugSetReputation(ctx context.Context, entries []ReputationEntry) error
+
+	// DebugDumpReputation returns the reputations of the entities for the entrypoint.
+	DebugDumpReputation(ctx context.Context) ([]ReputationEntry, error)
+}
@@ -0,0 +1,108 @@
+package aasdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestUserOperationJSON(t *testing.T) {
+	userOp := &UserOperation{
+		Sender:                        common.HexToAddress("0x01"),
+		Nonce:                         big.NewInt(2),
+		CallData:                      []byte{0x0a},
+		CallGasLimit:                  big.NewInt(0x10),
+		VerificationGasLimit:          big.NewInt(0x20),
+		PreVerificationGas:            big.NewInt(0x30),
+		MaxFeePerGas:                  big.NewInt(0x40),
+		MaxPriorityFeePerGas:          big.NewInt(0x50),
+		Signature:                     []byte{0x04},
+		Paymaster:                     common.HexToAddress("0xbb"),
+		PaymasterData:                 []byte{0x03},
+		PaymasterVerificationGasLimit: big.NewInt(0x60),
+		PaymasterPostOpGasLimit:       big.NewInt(0x70),
+		Factory:                       common.HexToAddress("0xaa"),
+		FactoryData:                   []byte{0x01, 0x02},
+		Eip7702Auth: &Eip7702Auth{
+			ChainId: big.NewInt(1),
+			Address: common.HexToAddress("0xcc"),
+			Nonce:   3,
+			YParity: 1,
+			R:       big.NewInt(5),
+			S:       big.NewInt(6),
+		},
+		Salt: big.NewInt(7),
+	}
+	data, err := json.Marshal(userOp)
+	if err != nil {
+		t.Fatalf("Failed to marshal user operation: %v", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Failed to unmarshal user operation fields: %v", err)
+	}
+	if _, ok := fields["salt"]; ok {
+		t.Errorf("Salt should not be encoded: %s", data)
+	}
+	if string(fields["callGasLimit"]) != `"0x10"` {
+		t.Errorf("Unexpected callGasLimit in %s", data)
+	}
+
+	var decoded UserOperation
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal user operation: %v", err)
+	}
+	if decoded.Sender != userOp.Sender || decoded.Nonce.Cmp(userOp.Nonce) != 0 || decoded.PaymasterPostOpGasLimit.Cmp(userOp.PaymasterPostOpGasLimit) != 0 {
+		t.Errorf("Unexpected user operation %+v", decoded)
+	}
+	if decoded.Factory != userOp.Factory || !bytes.Equal(decoded.FactoryData, userOp.FactoryData) || !bytes.Equal(decoded.PaymasterData, userOp.PaymasterData) {
+		t.Errorf("Unexpected factory or paymaster data %+v", decoded)
+	}
+	if decoded.Eip7702Auth == nil || decoded.Eip7702Auth.Nonce != 3 || decoded.Eip7702Auth.S.Int64() != 6 {
+		t.Errorf("Unexpected authorization %+v", decoded.Eip7702Auth)
+	}
+	if decoded.Salt != nil {
+		t.Errorf("Salt should not be encoded")
+	}
+
+	// The bundler format is reproduced
+	again, err := json.Marshal(&decoded)
+	if err != nil {
+		t.Fatalf("Failed to marshal user operation: %v", err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Errorf("Round trip mismatch:\n%s\n%s", data, again)
+	}
+}
+
+func TestUserOperationJSONV06(t *testing.T) {
+	// The V0.6.0 initCode and paymasterAndData are split, and encoded back in the V0.7.0 format
+	var decoded UserOperation
+	if err := json.Unmarshal([]byte(`{"sender":"0x0000000000000000000000000000000000000001","callData":"0x","signature":"0x",
+		"initCode":"0x00000000000000000000000000000000000000aa0102","paymasterAndData":"0x00000000000000000000000000000000000000bb03"}`), &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal user operation: %v", err)
+	}
+	data, err := json.Marshal(&decoded)
+	if err != nil {
+		t.Fatalf("Failed to marshal user operation: %v", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Failed to unmarshal user operation fields: %v", err)
+	}
+	for _, key := range []string{"factory", "factoryData", "paymaster", "paymasterData"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("Expected %s in %s", key, data)
+		}
+	}
+
+	// An initCode or paymasterAndData shorter than an address isn't dropped silently
+	for _, field := range []string{"initCode", "paymasterAndData"} {
+		if err := json.Unmarshal([]byte(`{"`+field+`":"0x0102"}`), &decoded); err == nil {
+			t.Errorf("Expected an error for a short %s", field)
+		}
+	}
+}
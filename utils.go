@@ -121,12 +121,22 @@ func PackInt(a *big.Int, b *big.Int) common.Hash {
 	return result
 }
 
+// UnpackInt is the inverse of PackInt, it splits the hash into its two 16 bytes big.Ints.
+func UnpackInt(packed common.Hash) (*big.Int, *big.Int) {
+	return new(big.Int).SetBytes(packed[:16]), new(big.Int).SetBytes(packed[16:])
+}
+
 // PackUserOperation packs a user operation into a PackedUserOperation.
+// The paymasterAndData is empty without paymaster.
 // It panics if the user operation is nil.
 func PackUserOperation(userOp *UserOperation) entrypoint.PackedUserOperation {
 	if userOp == nil {
 		panic("nil user operation")
 	}
+	paymasterAndData := []byte{}
+	if userOp.Paymaster != (common.Address{}) {
+		paymasterAndData = PackPaymasterAndData(userOp.Paymaster, userOp.PaymasterVerificationGasLimit, userOp.PaymasterPostOpGasLimit, userOp.PaymasterData)
+	}
 	return entrypoint.PackedUserOperation{
 		Sender:             userOp.Sender,
 		Nonce:              userOp.Nonce,
@@ -134,8 +144,46 @@ func PackUserOperation(userOp *UserOperation) entrypoint.PackedUserOperation {
 		AccountGasLimits:   PackInt(userOp.VerificationGasLimit, userOp.CallGasLimit),
 		PreVerificationGas: userOp.PreVerificationGas,
 		GasFees:            PackInt(userOp.MaxPriorityFeePerGas, userOp.MaxFeePerGas),
-		PaymasterAndData:   PackPaymasterAndData(userOp.Paymaster, userOp.PaymasterVerificationGasLimit, userOp.PaymasterPostOpGasLimit, userOp.PaymasterData),
+		PaymasterAndData:   paymasterAndData,
 		Signature:          userOp.Signature,
 		InitCode:           userOp.InitCode,
 	}
 }
+
+// UnpackUserOperation is the inverse of PackUserOperation, it unpacks the gas limits and fees,
+// and splits the initCode and paymasterAndData into their fields.
+// It returns an error if the initCode or paymasterAndData are too short.
+func UnpackUserOperation(packed *entrypoint.PackedUserOperation) (*UserOperation, error) {
+	if packed == nil {
+		return nil, fmt.Errorf("nil packed user operation")
+	}
+	userOp := &UserOperation{
+		Sender:             packed.Sender,
+		Nonce:              packed.Nonce,
+		CallData:           packed.CallData,
+		PreVerificationGas: packed.PreVerificationGas,
+		Signature:          packed.Signature,
+		InitCode:           packed.InitCode,
+	}
+	userOp.VerificationGasLimit, userOp.CallGasLimit = UnpackInt(packed.AccountGasLimits)
+	userOp.MaxPriorityFeePerGas, userOp.MaxFeePerGas = UnpackInt(packed.GasFees)
+
+	if len(packed.InitCode) > 0 {
+		if len(packed.InitCode) < common.AddressLength {
+			return nil, fmt.Errorf("initCode too short: %d bytes, expected at least %d", len(packed.InitCode), common.AddressLength)
+		}
+		userOp.Factory = common.BytesToAddress(packed.InitCode[:common.AddressLength])
+		userOp.FactoryData = packed.InitCode[common.AddressLength:]
+	}
+	if len(packed.PaymasterAndData) > 0 {
+		paymaster, verGasLimit, postOpGasLimit, data, err := UnpackPaymasterAndData(packed.PaymasterAndData)
+		if err != nil {
+			return nil, err
+		}
+		userOp.Paymaster = paymaster
+		userOp.PaymasterVerificationGasLimit = verGasLimit
+		userOp.PaymasterPostOpGasLimit = postOpGasLimit
+		userOp.PaymasterData = data
+	}
+	return userOp, nil
+}
@@ -0,0 +1,60 @@
+package aasdk
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypoint"
+)
+
+func TestUnpackUserOperation(t *testing.T) {
+	userOp := &UserOperation{
+		Sender:                        common.HexToAddress("0x01"),
+		Nonce:                         big.NewInt(2),
+		CallData:                      []byte{0x0a},
+		CallGasLimit:                  big.NewInt(0x10),
+		VerificationGasLimit:          big.NewInt(0x20),
+		PreVerificationGas:            big.NewInt(0x30),
+		MaxFeePerGas:                  big.NewInt(0x40),
+		MaxPriorityFeePerGas:          big.NewInt(0x50),
+		Signature:                     []byte{0x04},
+		Paymaster:                     common.HexToAddress("0xbb"),
+		PaymasterData:                 []byte{0x03},
+		PaymasterVerificationGasLimit: big.NewInt(0x60),
+		PaymasterPostOpGasLimit:       big.NewInt(0x70),
+		InitCode:                      append(common.HexToAddress("0xaa").Bytes(), 0x01, 0x02),
+	}
+	packed := PackUserOperation(userOp)
+	unpacked, err := UnpackUserOperation(&packed)
+	if err != nil {
+		t.Fatalf("Failed to unpack user operation: %v", err)
+	}
+	if unpacked.CallGasLimit.Int64() != 0x10 || unpacked.VerificationGasLimit.Int64() != 0x20 {
+		t.Errorf("Unexpected gas limits %s and %s", unpacked.CallGasLimit, unpacked.VerificationGasLimit)
+	}
+	if unpacked.MaxFeePerGas.Int64() != 0x40 || unpacked.MaxPriorityFeePerGas.Int64() != 0x50 {
+		t.Errorf("Unexpected fees %s and %s", unpacked.MaxFeePerGas, unpacked.MaxPriorityFeePerGas)
+	}
+	if unpacked.Paymaster != userOp.Paymaster || unpacked.PaymasterVerificationGasLimit.Int64() != 0x60 ||
+		unpacked.PaymasterPostOpGasLimit.Int64() != 0x70 || !bytes.Equal(unpacked.PaymasterData, userOp.PaymasterData) {
+		t.Errorf("Unexpected paymaster fields %+v", unpacked)
+	}
+	if unpacked.Factory != common.HexToAddress("0xaa") || !bytes.Equal(unpacked.FactoryData, []byte{0x01, 0x02}) {
+		t.Errorf("Unexpected factory %s with data %x", unpacked.Factory.Hex(), unpacked.FactoryData)
+	}
+	repacked := PackUserOperation(unpacked)
+	if repacked.AccountGasLimits != packed.AccountGasLimits || repacked.GasFees != packed.GasFees ||
+		!bytes.Equal(repacked.PaymasterAndData, packed.PaymasterAndData) || !bytes.Equal(repacked.InitCode, packed.InitCode) {
+		t.Errorf("Repacked user operation mismatch")
+	}
+
+	// Malformed lengths are rejected
+	if _, err := UnpackUserOperation(&entrypoint.PackedUserOperation{InitCode: []byte{0x01}}); err == nil {
+		t.Errorf("Expected an error for a short initCode")
+	}
+	if _, err := UnpackUserOperation(&entrypoint.PackedUserOperation{PaymasterAndData: make([]byte, PaymasterDataOffset-1)}); err == nil {
+		t.Errorf("Expected an error for a short paymasterAndData")
+	}
+}
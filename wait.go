@@ -0,0 +1,240 @@
+package aasdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// WaitStatus is the outcome of waiting for a user operation.
+type WaitStatus string
+
+const (
+	// WaitStatusIncluded means the user operation was included and succeeded.
+	WaitStatusIncluded WaitStatus = "included"
+	// WaitStatusReverted means the user operation was included but its execution reverted.
+	WaitStatusReverted WaitStatus = "reverted"
+	// WaitStatusPending means the user operation was still in the mempool at the deadline.
+	WaitStatusPending WaitStatus = "pending"
+	// WaitStatusNotFound means the bundler never knew the user operation until the deadline.
+	WaitStatusNotFound WaitStatus = "not_found"
+	// WaitStatusDropped means the user operation left the mempool without being included.
+	WaitStatusDropped WaitStatus = "dropped"
+	// WaitStatusUnconfirmed means the user operation was included but didn't have the confirmations at the deadline.
+	// The receipt is the latest one seen, the inclusion may still be reorged out.
+	WaitStatusUnconfirmed WaitStatus = "unconfirmed"
+)
+
+var (
+	// DefaultWaitOptions is used by WaitForUserOperation, the interval is Config.WaitReceiptInterval if set.
+	DefaultWaitOptions = WaitOptions{
+		Interval:           time.Second,
+		MaxInterval:        10 * time.Second,
+		Multiplier:         1.5,
+		Timeout:            defaultWaitTimeout,
+		MaxTransientErrors: 5,
+		DroppedAfter:       2,
+	}
+)
+
+// WaitOptions configures WaitForUserOperationWithOptions.
+type WaitOptions struct {
+	// The first polling interval.
+	Interval time.Duration
+	// The maximum polling interval.
+	MaxInterval time.Duration
+	// The factor applied to the interval after each poll, 1 for a fixed interval.
+	Multiplier float64
+	// The timeout, only applied when the context has no deadline. 0 waits until the context is done.
+	Timeout time.Duration
+	// The consecutive transient errors tolerated before giving up.
+	MaxTransientErrors int
+	// The blocks to wait on top of the inclusion block before returning the receipt.
+	Confirmations uint64
+	// The consecutive polls an operation seen in the mempool must be missing for to be reported as dropped.
+	// 0 disables the mempool lookups.
+	DroppedAfter int
+}
+
+// WaitResult is the outcome of waiting for a user operation.
+type WaitResult struct {
+	Status  WaitStatus
+	Receipt *UserOpReceipt // set when included, reverted or unconfirmed
+}
+
+// WaitForUserOperation waits for the receipt of the user operation with DefaultWaitOptions.
+// It returns an error if the user operation isn't included before the deadline.
+func (c *Client) WaitForUserOperation(ctx context.Context, hash common.Hash) (*UserOpReceipt, error) {
+	opts := DefaultWaitOptions
+	if c.config.WaitReceiptInterval > 0 {
+		opts.Interval = c.config.WaitReceiptInterval
+	}
+	result, err := c.WaitForUserOperationWithOptions(ctx, hash, opts)
+	if err != nil {
+		return nil, err
+	}
+	if result.Receipt == nil {
+		return nil, fmt.Errorf("no receipt found for user operation %s: %s", hash.Hex(), result.Status)
+	}
+	return result.Receipt, nil
+}
+
+// WaitForUserOperationWithOptions polls the bundler until the user operation is included,
+// dropped or the deadline is reached, which is reported as WaitStatusUnconfirmed, WaitStatusPending or WaitStatusNotFound.
+// It returns an error if the context is cancelled, or on too many or non-transient errors.
+func (c *Client) WaitForUserOperationWithOptions(ctx context.Context, hash common.Hash, opts WaitOptions) (*WaitResult, error) {
+	if _, ok := ctx.Deadline(); !ok && opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultWaitOptions.Interval
+	}
+
+	var (
+		state waitState
+		errs  int // the consecutive transient errors
+	)
+	for {
+		result, err := c.pollUserOperation(ctx, hash, opts, &state)
+		if err == nil && result != nil {
+			return result, nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return c.waitDeadline(ctx, &state)
+			}
+			errs++
+			if !isTransient(err) || errs > opts.MaxTransientErrors {
+				return nil, fmt.Errorf("error waiting for user operation %s: %w", hash.Hex(), err)
+			}
+		} else {
+			errs = 0
+		}
+
+		if err := sleepContext(ctx, interval); err != nil {
+			return c.waitDeadline(ctx, &state)
+		}
+		if opts.Multiplier > 1 {
+			interval = time.Duration(float64(interval) * opts.Multiplier)
+		}
+		if opts.MaxInterval > 0 && interval > opts.MaxInterval {
+			interval = opts.MaxInterval
+		}
+	}
+}
+
+// waitState tracks the inclusion and the mempool lookups across polls.
+type waitState struct {
+	included  *UserOpReceipt // the latest receipt of the operation waiting for confirmations
+	seen      bool           // whether the operation was seen in the mempool
+	missing   int            // the consecutive polls the seen operation was missing for
+	noMempool bool           // whether the bundler doesn't support eth_getUserOperationByHash
+}
+
+// pollUserOperation checks the user operation once, it returns a nil result if it's still pending.
+func (c *Client) pollUserOperation(ctx context.Context, hash common.Hash, opts WaitOptions, state *waitState) (*WaitResult, error) {
+	receipt, err := c.GetUserOpReceipt(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	state.included = receipt
+	if receipt != nil {
+		if opts.Confirmations > 0 {
+			confirmed, err := c.isConfirmed(ctx, receipt, opts.Confirmations)
+			if err != nil || !confirmed {
+				return nil, err
+			}
+			// Make sure the inclusion survived a reorg while confirming.
+			if receipt, err = c.GetUserOpReceipt(ctx, hash); err != nil || receipt == nil {
+				if err == nil {
+					state.included = nil
+				}
+				return nil, err
+			}
+		}
+		status := WaitStatusIncluded
+		if !receipt.Success {
+			status = WaitStatusReverted
+		}
+		return &WaitResult{Status: status, Receipt: receipt}, nil
+	}
+
+	if opts.DroppedAfter <= 0 || state.noMempool {
+		return nil, nil
+	}
+	byHash, err := c.GetUserOperationByHash(ctx, hash)
+	if err != nil {
+		// Keep waiting for the receipt without the mempool lookups if the bundler doesn't support them.
+		if isMethodNotFound(err) {
+			state.noMempool = true
+			return nil, nil
+		}
+		return nil, err
+	}
+	if byHash != nil {
+		state.seen = true
+		state.missing = 0
+		return nil, nil
+	}
+	if state.seen {
+		state.missing++
+		if state.missing >= opts.DroppedAfter {
+			return &WaitResult{Status: WaitStatusDropped}, nil
+		}
+	}
+	return nil, nil
+}
+
+// isConfirmed checks if the block of the receipt has enough confirmations.
+func (c *Client) isConfirmed(ctx context.Context, receipt *UserOpReceipt, confirmations uint64) (bool, error) {
+	if receipt.Receipt == nil {
+		return false, fmt.Errorf("no transaction receipt")
+	}
+	head, err := c.eth.BlockNumber(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error getting block number: %w", err)
+	}
+	included := receipt.Receipt.BlockNumber
+	if included == nil {
+		return false, fmt.Errorf("no block number in transaction receipt")
+	}
+	depth := new(big.Int).Sub(new(big.Int).SetUint64(head), included)
+	return depth.Cmp(new(big.Int).SetUint64(confirmations)) >= 0, nil
+}
+
+// waitDeadline reports the status at the deadline, or the error if the context was cancelled.
+func (c *Client) waitDeadline(ctx context.Context, state *waitState) (*WaitResult, error) {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, ctx.Err()
+	}
+	if state.included != nil {
+		return &WaitResult{Status: WaitStatusUnconfirmed, Receipt: state.included}, nil
+	}
+	if state.seen {
+		return &WaitResult{Status: WaitStatusPending}, nil
+	}
+	return &WaitResult{Status: WaitStatusNotFound}, nil
+}
+
+// isTransient checks if the error may go away on its own: network errors, 429 and 5xx responses,
+// and bundler errors other than invalid params and method not found.
+func isTransient(err error) bool {
+	var bundlerErr *BundlerError
+	if errors.As(err, &bundlerErr) {
+		return bundlerErr.Code != CodeInvalidUserOpFields && bundlerErr.Code != codeMethodNotFound
+	}
+	return retriable(err)
+}
+
+// isMethodNotFound checks if the bundler doesn't support the method.
+func isMethodNotFound(err error) bool {
+	var bundlerErr *BundlerError
+	return errors.As(err, &bundlerErr) && bundlerErr.Code == codeMethodNotFound
+}
@@ -0,0 +1,225 @@
+package aasdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// newBundlerStub starts a bundler answering eth_getUserOperationReceipt and eth_getUserOperationByHash,
+// and a node answering eth_blockNumber, with the results of the handler for the poll count,
+// or with an error for results prefixed by "error:".
+func newBundlerStub(t *testing.T, handler func(method string, poll int32) string) (*Client, func()) {
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Id     uint64 `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		poll := polls.Load()
+		if request.Method == "eth_getUserOperationReceipt" {
+			poll = polls.Add(1)
+		}
+		response := map[string]any{"jsonrpc": "2.0", "id": request.Id}
+		if result := handler(request.Method, poll); strings.HasPrefix(result, "error:") {
+			response["error"] = json.RawMessage(strings.TrimPrefix(result, "error:"))
+		} else {
+			response["result"] = json.RawMessage(result)
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	eth, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create eth client: %v", err)
+	}
+	client := &Client{
+		config:  &Config{},
+		eth:     eth,
+		bundler: newBundlerPool([]string{server.URL}, nil, nil, FailoverPolicy{MaxAttempts: 1}),
+	}
+	return client, server.Close
+}
+
+func TestWaitForUserOperationReverted(t *testing.T) {
+	client, stop := newBundlerStub(t, func(method string, poll int32) string {
+		if method == "eth_getUserOperationReceipt" && poll >= 2 {
+			return `{"success":false,"actualGasCost":"0x1"}`
+		}
+		if method == "eth_getUserOperationByHash" {
+			return `{"userOperation":{"sender":"0x0000000000000000000000000000000000000001"}}`
+		}
+		return "null"
+	})
+	defer stop()
+
+	result, err := client.WaitForUserOperationWithOptions(context.Background(), common.Hash{1}, WaitOptions{
+		Interval:     time.Millisecond,
+		Timeout:      time.Second,
+		DroppedAfter: 2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to wait: %v", err)
+	}
+	if result.Status != WaitStatusReverted || result.Receipt == nil {
+		t.Errorf("Expected reverted with receipt, got %+v", result)
+	}
+}
+
+func TestWaitForUserOperationDropped(t *testing.T) {
+	client, stop := newBundlerStub(t, func(method string, poll int32) string {
+		if method == "eth_getUserOperationByHash" && poll < 2 {
+			return `{"userOperation":{"sender":"0x0000000000000000000000000000000000000001"}}`
+		}
+		return "null"
+	})
+	defer stop()
+
+	result, err := client.WaitForUserOperationWithOptions(context.Background(), common.Hash{1}, WaitOptions{
+		Interval:     time.Millisecond,
+		Timeout:      time.Second,
+		DroppedAfter: 2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to wait: %v", err)
+	}
+	if result.Status != WaitStatusDropped {
+		t.Errorf("Expected dropped, got %s", result.Status)
+	}
+}
+
+func TestWaitForUserOperationNotFound(t *testing.T) {
+	client, stop := newBundlerStub(t, func(method string, poll int32) string {
+		return "null"
+	})
+	defer stop()
+
+	// The caller deadline is respected
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result, err := client.WaitForUserOperationWithOptions(ctx, common.Hash{1}, WaitOptions{
+		Interval:     5 * time.Millisecond,
+		Timeout:      time.Hour,
+		DroppedAfter: 2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to wait: %v", err)
+	}
+	if result.Status != WaitStatusNotFound {
+		t.Errorf("Expected not found, got %s", result.Status)
+	}
+}
+
+func TestWaitForUserOperationMethodNotFound(t *testing.T) {
+	var lookups atomic.Int32
+	client, stop := newBundlerStub(t, func(method string, poll int32) string {
+		if method == "eth_getUserOperationByHash" {
+			lookups.Add(1)
+			return `error:{"code":-32601,"message":"method not found"}`
+		}
+		if poll >= 3 {
+			return `{"success":true,"actualGasCost":"0x1"}`
+		}
+		return "null"
+	})
+	defer stop()
+
+	// The wait goes on with the receipt only once the bundler doesn't support the mempool lookup
+	result, err := client.WaitForUserOperationWithOptions(context.Background(), common.Hash{1}, WaitOptions{
+		Interval:           time.Millisecond,
+		Timeout:            time.Second,
+		MaxTransientErrors: 0,
+		DroppedAfter:       2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to wait: %v", err)
+	}
+	if result.Status != WaitStatusIncluded {
+		t.Errorf("Expected included, got %s", result.Status)
+	}
+	if n := lookups.Load(); n != 1 {
+		t.Errorf("Expected a single mempool lookup, got %d", n)
+	}
+	if isTransient(&BundlerError{Code: -32601}) {
+		t.Errorf("Expected method not found to be non-transient")
+	}
+}
+
+func TestWaitForUserOperationConfirmations(t *testing.T) {
+	// The operation is included in block 0x10, the head moves from 0x11 to 0x13 after the third poll
+	client, stop := newBundlerStub(t, func(method string, poll int32) string {
+		switch method {
+		case "eth_getUserOperationReceipt":
+			return `{"success":true,"actualGasCost":"0x1","receipt":{"blockNumber":"0x10"}}`
+		case "eth_blockNumber":
+			if poll > 3 {
+				return `"0x13"`
+			}
+			return `"0x11"`
+		}
+		return "null"
+	})
+	defer stop()
+	opts := WaitOptions{
+		Interval:      time.Millisecond,
+		Timeout:       time.Second,
+		Confirmations: 3,
+	}
+
+	result, err := client.WaitForUserOperationWithOptions(context.Background(), common.Hash{1}, opts)
+	if err != nil {
+		t.Fatalf("Failed to wait: %v", err)
+	}
+	if result.Status != WaitStatusIncluded || result.Receipt == nil || result.Receipt.Receipt.BlockNumber.Int64() != 16 {
+		t.Errorf("Expected included with receipt, got %+v", result)
+	}
+
+	// Included but not confirmed at the deadline, the receipt is kept
+	opts.Confirmations = 10
+	opts.Timeout = 50 * time.Millisecond
+	result, err = client.WaitForUserOperationWithOptions(context.Background(), common.Hash{1}, opts)
+	if err != nil {
+		t.Fatalf("Failed to wait: %v", err)
+	}
+	if result.Status != WaitStatusUnconfirmed || result.Receipt == nil || !result.Receipt.Success {
+		t.Errorf("Expected unconfirmed with receipt, got %+v", result)
+	}
+}
+
+func TestWaitForUserOperationReorged(t *testing.T) {
+	// The receipt disappears after the first poll, as the inclusion was reorged out
+	client, stop := newBundlerStub(t, func(method string, poll int32) string {
+		switch method {
+		case "eth_getUserOperationReceipt":
+			if poll == 1 {
+				return `{"success":true,"actualGasCost":"0x1","receipt":{"blockNumber":"0x10"}}`
+			}
+		case "eth_blockNumber":
+			return `"0x10"`
+		}
+		return "null"
+	})
+	defer stop()
+
+	result, err := client.WaitForUserOperationWithOptions(context.Background(), common.Hash{1}, WaitOptions{
+		Interval:      time.Millisecond,
+		Timeout:       50 * time.Millisecond,
+		Confirmations: 2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to wait: %v", err)
+	}
+	if result.Status != WaitStatusNotFound || result.Receipt != nil {
+		t.Errorf("Expected not found without receipt, got %+v", result)
+	}
+}
@@ -0,0 +1,425 @@
+package aasdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypoint"
+)
+
+var (
+	// ErrWatcherClosed is returned by the futures still pending when the watcher is closed.
+	ErrWatcherClosed = errors.New("user operation watcher closed")
+)
+
+const (
+	defaultReconnectDelay     = time.Second
+	defaultMaxReconnectDelay  = 30 * time.Second
+	defaultRecentOps          = 1024
+	defaultBackfillBlockRange = 1000
+)
+
+type UserOpWatcherConfig struct {
+	// The websocket url of the node.
+	NodeUrl string
+	// The entrypoint address.
+	Entrypoint common.Address
+	// The block to backfill the events from when the watcher starts, defaults to the latest block.
+	StartBlock *uint64
+	// The delay before the first reconnection, doubled up to MaxReconnectDelay. Defaults to 1s.
+	ReconnectDelay time.Duration
+	// The maximum delay between reconnections. Defaults to 30s.
+	MaxReconnectDelay time.Duration
+	// The user operations kept after their events, to resolve the ones watched late. Defaults to 1024.
+	RecentOps int
+	// The maximum blocks per eth_getLogs request when backfilling. Defaults to 1000.
+	BackfillBlockRange uint64
+	// OnError is called with the errors of the watcher once started, such as a dropped subscription,
+	// a failed backfill or reconnection. The watcher keeps reconnecting. Optional.
+	OnError func(error)
+}
+
+// UserOpEvent is the on-chain outcome of a user operation, from its UserOperationEvent.
+type UserOpEvent struct {
+	UserOpHash    common.Hash
+	Sender        common.Address
+	Paymaster     common.Address
+	Nonce         *big.Int
+	Success       bool
+	ActualGasCost *big.Int
+	ActualGasUsed *big.Int
+	// The decoded revert of the execution, from UserOperationRevertReason.
+	RevertReason error
+	// The decoded revert of the paymaster postOp, from PostOpRevertReason.
+	PostOpRevertReason error
+	// The UserOperationEvent log.
+	Log types.Log
+}
+
+// UserOpFuture resolves when the UserOperationEvent of a user operation is seen.
+type UserOpFuture struct {
+	done  chan struct{}
+	event *UserOpEvent
+	err   error
+}
+
+// Done is closed when the future is resolved.
+func (f *UserOpFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for the event of the user operation, or until the context is done.
+func (f *UserOpFuture) Wait(ctx context.Context) (*UserOpEvent, error) {
+	select {
+	case <-f.done:
+		return f.event, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *UserOpFuture) resolve(event *UserOpEvent, err error) {
+	f.event, f.err = event, err
+	close(f.done)
+}
+
+// pendingOp is a watched user operation with the revert reasons seen before its UserOperationEvent,
+// or a recent one not watched yet.
+type pendingOp struct {
+	future             *UserOpFuture // nil if not watched
+	event              *UserOpEvent  // set once included if not watched
+	revertReason       []byte
+	postOpRevertReason []byte
+}
+
+// watcherBackend is the node API used to backfill the events.
+type watcherBackend interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+}
+
+// UserOpWatcher confirms user operations from the EntryPoint events, multiplexing all the
+// watched hashes over a single log subscription. It reconnects when the subscription drops
+// and backfills the blocks it missed.
+type UserOpWatcher struct {
+	config   UserOpWatcherConfig
+	filterer *entrypoint.EntryPointFilterer // only used to parse the logs
+	topics   []common.Hash
+
+	mu        sync.Mutex
+	pending   map[common.Hash]*pendingOp
+	recent    *lru.Cache // the recent *pendingOp not watched or resolved, by hash
+	lastBlock uint64     // the block the next backfill starts from
+	closed    bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewUserOpWatcher connects to the node and starts watching the EntryPoint events.
+// It fails if the node doesn't support log subscriptions, e.g. over http.
+func NewUserOpWatcher(ctx context.Context, config UserOpWatcherConfig) (*UserOpWatcher, error) {
+	w, err := newUserOpWatcher(config)
+	if err != nil {
+		return nil, err
+	}
+	client, sub, logs, err := w.subscribe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if config.StartBlock != nil {
+		w.lastBlock = *config.StartBlock
+	} else if w.lastBlock, err = client.BlockNumber(ctx); err != nil {
+		sub.Unsubscribe()
+		client.Close()
+		return nil, fmt.Errorf("error getting block number: %v", err)
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	go w.loop(runCtx, client, sub, logs)
+	return w, nil
+}
+
+// newUserOpWatcher creates a UserOpWatcher that is not started.
+func newUserOpWatcher(config UserOpWatcherConfig) (*UserOpWatcher, error) {
+	if config.ReconnectDelay <= 0 {
+		config.ReconnectDelay = defaultReconnectDelay
+	}
+	if config.MaxReconnectDelay <= 0 {
+		config.MaxReconnectDelay = defaultMaxReconnectDelay
+	}
+	if config.RecentOps <= 0 {
+		config.RecentOps = defaultRecentOps
+	}
+	if config.BackfillBlockRange == 0 {
+		config.BackfillBlockRange = defaultBackfillBlockRange
+	}
+	parsed, err := entrypoint.EntryPointMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("error getting entrypoint ABI: %v", err)
+	}
+	filterer, err := entrypoint.NewEntryPointFilterer(config.Entrypoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating entrypoint filterer: %v", err)
+	}
+	recent, err := lru.New(config.RecentOps)
+	if err != nil {
+		return nil, fmt.Errorf("error creating recent operations cache: %v", err)
+	}
+	return &UserOpWatcher{
+		config:   config,
+		filterer: filterer,
+		topics: []common.Hash{
+			parsed.Events["UserOperationEvent"].ID,
+			parsed.Events["UserOperationRevertReason"].ID,
+			parsed.Events["PostOpRevertReason"].ID,
+		},
+		pending: make(map[common.Hash]*pendingOp),
+		recent:  recent,
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// Watch returns the future of the user operation, the same one if it's already watched.
+// The future of an operation among the RecentOps last ones seen by the watcher resolves immediately.
+// Operations included before the watcher started are only found from StartBlock.
+func (w *UserOpWatcher) Watch(hash common.Hash) *UserOpFuture {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if op, ok := w.pending[hash]; ok {
+		return op.future
+	}
+	future := &UserOpFuture{done: make(chan struct{})}
+	if w.closed {
+		future.resolve(nil, ErrWatcherClosed)
+		return future
+	}
+	op := &pendingOp{}
+	if value, ok := w.recent.Get(hash); ok {
+		op = value.(*pendingOp)
+		if op.event != nil {
+			future.resolve(op.event, nil)
+			return future
+		}
+		w.recent.Remove(hash)
+	}
+	op.future = future
+	w.pending[hash] = op
+	return future
+}
+
+// Unwatch stops watching the user operation, its future never resolves.
+func (w *UserOpWatcher) Unwatch(hash common.Hash) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.pending, hash)
+}
+
+// Close stops the watcher, the pending futures resolve with ErrWatcherClosed.
+func (w *UserOpWatcher) Close() {
+	w.cancel()
+	<-w.done
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	for hash, op := range w.pending {
+		op.future.resolve(nil, ErrWatcherClosed)
+		delete(w.pending, hash)
+	}
+}
+
+// loop runs the subscription, reconnecting with backoff until the watcher is closed.
+func (w *UserOpWatcher) loop(ctx context.Context, client *ethclient.Client, sub ethereum.Subscription, logs chan types.Log) {
+	defer close(w.done)
+	delay := w.config.ReconnectDelay
+	for {
+		healthy, err := w.run(ctx, client, sub, logs)
+		sub.Unsubscribe()
+		client.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		w.reportError(err)
+		if healthy {
+			delay = w.config.ReconnectDelay
+		}
+
+		for {
+			if err := sleepContext(ctx, delay); err != nil {
+				return
+			}
+			delay = min(delay*2, w.config.MaxReconnectDelay)
+			if client, sub, logs, err = w.subscribe(ctx); err == nil {
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			w.reportError(err)
+		}
+	}
+}
+
+// subscribe dials the node and subscribes to the EntryPoint events.
+func (w *UserOpWatcher) subscribe(ctx context.Context) (*ethclient.Client, ethereum.Subscription, chan types.Log, error) {
+	client, err := ethclient.DialContext(ctx, w.config.NodeUrl)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error creating eth client: %v", err)
+	}
+	logs := make(chan types.Log, 128)
+	sub, err := client.SubscribeFilterLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{w.config.Entrypoint},
+		Topics:    [][]common.Hash{w.topics},
+	}, logs)
+	if err != nil {
+		client.Close()
+		return nil, nil, nil, fmt.Errorf("error subscribing to the entrypoint events: %w", err)
+	}
+	return client, sub, logs, nil
+}
+
+// run backfills the missed blocks and processes the logs of the subscription until it drops.
+// It returns whether the backfill succeeded, so a failing node isn't reconnected to at the shortest delay,
+// and the error that stopped it.
+func (w *UserOpWatcher) run(ctx context.Context, backend watcherBackend, sub ethereum.Subscription, logs <-chan types.Log) (bool, error) {
+	// Backfill after subscribing so no block falls in between, duplicates are ignored.
+	if err := w.backfill(ctx, backend, w.config.BackfillBlockRange); err != nil {
+		return false, fmt.Errorf("error backfilling the entrypoint events: %w", err)
+	}
+	for {
+		select {
+		case log := <-logs:
+			w.handleLog(log)
+		case err := <-sub.Err():
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return true, fmt.Errorf("entrypoint events subscription dropped: %w", err)
+		case <-ctx.Done():
+			return true, nil
+		}
+	}
+}
+
+// reportError passes the error to OnError, if any.
+func (w *UserOpWatcher) reportError(err error) {
+	if err != nil && w.config.OnError != nil {
+		w.config.OnError(err)
+	}
+}
+
+// backfill replays the events since the last processed block, in requests of blockRange blocks.
+func (w *UserOpWatcher) backfill(ctx context.Context, backend watcherBackend, blockRange uint64) error {
+	head, err := backend.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	start := w.lastBlock
+	w.mu.Unlock()
+
+	for from := start; from <= head; from += blockRange {
+		to := min(from+blockRange-1, head)
+		// The revert reasons precede the UserOperationEvent of the same operation in the logs order.
+		logs, err := backend.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: []common.Address{w.config.Entrypoint},
+			Topics:    [][]common.Hash{w.topics},
+		})
+		if err != nil {
+			return err
+		}
+		for _, log := range logs {
+			w.handleLog(log)
+		}
+		w.advance(to)
+	}
+	return nil
+}
+
+// handleLog records a revert reason or resolves the future of a UserOperationEvent.
+// The events of the operations not watched are kept in the recent operations.
+func (w *UserOpWatcher) handleLog(log types.Log) {
+	if log.Removed || len(log.Topics) < 2 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	hash := log.Topics[1]
+	op, ok := w.pending[hash]
+	if !ok {
+		value, ok := w.recent.Get(hash)
+		if !ok {
+			value = &pendingOp{}
+			w.recent.Add(hash, value)
+		}
+		op = value.(*pendingOp)
+	}
+
+	switch log.Topics[0] {
+	case w.topics[1]:
+		if event, err := w.filterer.ParseUserOperationRevertReason(log); err == nil {
+			op.revertReason = event.RevertReason
+		}
+	case w.topics[2]:
+		if event, err := w.filterer.ParsePostOpRevertReason(log); err == nil {
+			op.postOpRevertReason = event.RevertReason
+		}
+	case w.topics[0]:
+		event, err := w.filterer.ParseUserOperationEvent(log)
+		if err != nil {
+			return
+		}
+		op.event = &UserOpEvent{
+			UserOpHash:         event.UserOpHash,
+			Sender:             event.Sender,
+			Paymaster:          event.Paymaster,
+			Nonce:              event.Nonce,
+			Success:            event.Success,
+			ActualGasCost:      event.ActualGasCost,
+			ActualGasUsed:      event.ActualGasUsed,
+			RevertReason:       DecodeRevert(op.revertReason),
+			PostOpRevertReason: DecodeRevert(op.postOpRevertReason),
+			Log:                log,
+		}
+		// Keep the resolved operation so watching it again resolves too.
+		if op.future != nil {
+			op.future.resolve(op.event, nil)
+			op.future = nil
+			delete(w.pending, hash)
+			w.recent.Add(hash, op)
+		}
+	}
+	if log.BlockNumber > w.lastBlock {
+		w.lastBlock = log.BlockNumber
+	}
+}
+
+// advance moves the next backfill start to the block.
+func (w *UserOpWatcher) advance(block uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if block > w.lastBlock {
+		w.lastBlock = block
+	}
+}
+
+// NewUserOpWatcher starts a UserOpWatcher on the entrypoint of the client, over the websocket url of the node.
+func (c *Client) NewUserOpWatcher(ctx context.Context, wsUrl string) (*UserOpWatcher, error) {
+	return NewUserOpWatcher(ctx, UserOpWatcherConfig{
+		NodeUrl:    wsUrl,
+		Entrypoint: c.config.Entrypoint,
+	})
+}
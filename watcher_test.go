@@ -0,0 +1,249 @@
+package aasdk
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/lifenetwork-ai/aa-sdk-go/bindings/entrypoint"
+)
+
+func TestUserOpWatcherHandleLog(t *testing.T) {
+	parsed, err := entrypoint.EntryPointMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to parse entrypoint ABI: %v", err)
+	}
+	eventTopic := parsed.Events["UserOperationEvent"].ID
+	revertTopic := parsed.Events["UserOperationRevertReason"].ID
+	w, err := newUserOpWatcher(UserOpWatcherConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+
+	hash := common.Hash{0x01}
+	sender := common.HexToAddress("0x02")
+	future := w.Watch(hash)
+	if w.Watch(hash) != future {
+		t.Errorf("Expected the same future for the same hash")
+	}
+
+	reason, err := abi.Arguments{{Type: abi.Type{T: abi.StringTy}}}.Pack("insufficient balance")
+	if err != nil {
+		t.Fatalf("Failed to pack reason: %v", err)
+	}
+	revertData, err := parsed.Events["UserOperationRevertReason"].Inputs.NonIndexed().Pack(big.NewInt(0), append(errorSelector[:], reason...))
+	if err != nil {
+		t.Fatalf("Failed to pack revert reason: %v", err)
+	}
+	w.handleLog(types.Log{
+		Topics:      []common.Hash{revertTopic, hash, common.BytesToHash(sender.Bytes())},
+		Data:        revertData,
+		BlockNumber: 10,
+	})
+	select {
+	case <-future.Done():
+		t.Fatalf("Expected the future to wait for the UserOperationEvent")
+	default:
+	}
+
+	eventData, err := parsed.Events["UserOperationEvent"].Inputs.NonIndexed().Pack(big.NewInt(0), false, big.NewInt(100), big.NewInt(50))
+	if err != nil {
+		t.Fatalf("Failed to pack event: %v", err)
+	}
+	w.handleLog(types.Log{
+		Topics:      []common.Hash{eventTopic, hash, common.BytesToHash(sender.Bytes()), {}},
+		Data:        eventData,
+		BlockNumber: 10,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	event, err := future.Wait(ctx)
+	if err != nil {
+		t.Fatalf("Failed to wait for the event: %v", err)
+	}
+	if event.Success || event.Sender != sender || event.ActualGasCost.Int64() != 100 {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	var revertErr *RevertError
+	if !errors.As(event.RevertReason, &revertErr) || revertErr.Reason != "insufficient balance" {
+		t.Errorf("Expected decoded revert reason, got %v", event.RevertReason)
+	}
+	if len(w.pending) != 0 || w.lastBlock != 10 {
+		t.Errorf("Expected the operation to be resolved at block 10, got %d pending at block %d", len(w.pending), w.lastBlock)
+	}
+}
+
+// userOpEventLog returns a successful UserOperationEvent log of the hash at the block.
+func userOpEventLog(t *testing.T, hash common.Hash, block uint64) types.Log {
+	parsed, err := entrypoint.EntryPointMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to parse entrypoint ABI: %v", err)
+	}
+	data, err := parsed.Events["UserOperationEvent"].Inputs.NonIndexed().Pack(big.NewInt(0), true, big.NewInt(100), big.NewInt(50))
+	if err != nil {
+		t.Fatalf("Failed to pack event: %v", err)
+	}
+	return types.Log{
+		Topics:      []common.Hash{parsed.Events["UserOperationEvent"].ID, hash, {}, {}},
+		Data:        data,
+		BlockNumber: block,
+	}
+}
+
+func TestUserOpWatcherRecent(t *testing.T) {
+	w, err := newUserOpWatcher(UserOpWatcherConfig{RecentOps: 1})
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+
+	// The operation included before being watched resolves immediately
+	hash := common.Hash{0x01}
+	w.handleLog(userOpEventLog(t, hash, 10))
+	select {
+	case <-w.Watch(hash).Done():
+	default:
+		t.Fatalf("Expected the future of a recent operation to be resolved")
+	}
+	if event, err := w.Watch(hash).Wait(context.Background()); err != nil || !event.Success {
+		t.Errorf("Unexpected event %+v, error %v", event, err)
+	}
+
+	// The recent operations are bounded
+	w.handleLog(userOpEventLog(t, common.Hash{0x02}, 11))
+	select {
+	case <-w.Watch(hash).Done():
+		t.Errorf("Expected the oldest recent operation to be evicted")
+	default:
+	}
+}
+
+// fakeWatcherBackend serves the logs of the blocks and records the requested block ranges.
+type fakeWatcherBackend struct {
+	head   uint64
+	logs   []types.Log
+	ranges [][2]uint64
+	err    error // returned by FilterLogs, if any
+}
+
+func (b *fakeWatcherBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	return b.head, nil
+}
+
+func (b *fakeWatcherBackend) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	b.ranges = append(b.ranges, [2]uint64{from, to})
+	var logs []types.Log
+	for _, log := range b.logs {
+		if log.BlockNumber >= from && log.BlockNumber <= to {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func TestUserOpWatcherBackfill(t *testing.T) {
+	w, err := newUserOpWatcher(UserOpWatcherConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	w.lastBlock = 100
+	watched, early := common.Hash{0x01}, common.Hash{0x02}
+	future := w.Watch(watched)
+	backend := &fakeWatcherBackend{
+		head: 125,
+		logs: []types.Log{userOpEventLog(t, early, 105), userOpEventLog(t, watched, 123)},
+	}
+	if err := w.backfill(context.Background(), backend, 10); err != nil {
+		t.Fatalf("Failed to backfill: %v", err)
+	}
+
+	expected := [][2]uint64{{100, 109}, {110, 119}, {120, 125}}
+	if len(backend.ranges) != len(expected) {
+		t.Fatalf("Expected block ranges %v, got %v", expected, backend.ranges)
+	}
+	for i, r := range expected {
+		if backend.ranges[i] != r {
+			t.Errorf("Expected block range %v, got %v", r, backend.ranges[i])
+		}
+	}
+	if w.lastBlock != 125 {
+		t.Errorf("Expected last block 125, got %d", w.lastBlock)
+	}
+	select {
+	case <-future.Done():
+	default:
+		t.Errorf("Expected the watched operation to be resolved")
+	}
+	// The operation included before being watched is found by the backfill
+	select {
+	case <-w.Watch(early).Done():
+	default:
+		t.Errorf("Expected the backfilled operation to be resolved when watched")
+	}
+}
+
+func TestNewUserOpWatcherUnsupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request to the node")
+	}))
+	defer server.Close()
+
+	// A http node can't subscribe to the logs, the error is returned instead of retrying forever
+	start := uint64(0)
+	if _, err := NewUserOpWatcher(context.Background(), UserOpWatcherConfig{NodeUrl: server.URL, StartBlock: &start}); err == nil {
+		t.Errorf("Expected an error without log subscriptions")
+	}
+}
+
+func TestUserOpWatcherRun(t *testing.T) {
+	w, err := newUserOpWatcher(UserOpWatcherConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	ctx := context.Background()
+
+	// A failed backfill isn't reported as a healthy connection
+	failing := errors.New("eth_getLogs failed")
+	sub := event.NewSubscription(func(unsubscribed <-chan struct{}) error {
+		<-unsubscribed
+		return nil
+	})
+	healthy, err := w.run(ctx, &fakeWatcherBackend{head: 10, err: failing}, sub, nil)
+	sub.Unsubscribe()
+	if healthy || !errors.Is(err, failing) {
+		t.Errorf("Expected an unhealthy run with the backfill error, got %v, %v", healthy, err)
+	}
+
+	// A dropped subscription is reported after processing the logs
+	hash := common.Hash{0x01}
+	future := w.Watch(hash)
+	dropped := errors.New("connection reset")
+	logs := make(chan types.Log, 1)
+	logs <- userOpEventLog(t, hash, 11)
+	sub = event.NewSubscription(func(unsubscribed <-chan struct{}) error {
+		<-future.Done()
+		return dropped
+	})
+	healthy, err = w.run(ctx, &fakeWatcherBackend{head: 10}, sub, logs)
+	if !healthy || !errors.Is(err, dropped) {
+		t.Errorf("Expected a healthy run with the subscription error, got %v, %v", healthy, err)
+	}
+	select {
+	case <-future.Done():
+	default:
+		t.Errorf("Expected the watched operation to be resolved")
+	}
+}